@@ -9,9 +9,22 @@ import (
 
 type PluginSettings struct {
 	Workspace string							`json:"workspace"`
+	WarehouseId string						`json:"warehouseId"`
+	AccountId string							`json:"accountId"`
+	AccountHost string						`json:"accountHost"`
+	JobSLAs []JobSLA							`json:"jobSlas"`
 	Secrets *SecretPluginSettings `json:"-"`
 }
 
+// JobSLA declares the expected cadence and maximum duration of a job, or of every job
+// with a given tag (key or key=value).
+type JobSLA struct {
+	JobID string				`json:"jobId,omitempty"`
+	Tag string					`json:"tag,omitempty"`
+	Cadence string			`json:"cadence,omitempty"`
+	MaxDuration string	`json:"maxDuration,omitempty"`
+}
+
 type SecretPluginSettings struct {
 	ClientId string `json:"clientId"`
 	ClientSecret string `json:"clientSecret"`
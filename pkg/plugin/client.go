@@ -6,6 +6,7 @@ import (
 	"github.com/databricks/databricks-sdk-go"
 	"github.com/databricks/databricks-sdk-go/listing"
 	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/databricks/databricks-sdk-go/service/sql"
 )
 
 type DatabricksJobsService interface {
@@ -19,3 +20,27 @@ type workspaceClientWrapper struct {
 func (w *workspaceClientWrapper) ListRuns(ctx context.Context, request jobs.ListRunsRequest) listing.Iterator[jobs.BaseRun] {
 	return w.client.Jobs.ListRuns(ctx, request)
 }
+
+// listQueryHistory pages through the query history API, which returns a single page per call
+// rather than an iterator.
+func listQueryHistory(w *databricks.WorkspaceClient, request sql.ListQueryHistoryRequest) listing.Iterator[sql.QueryInfo] {
+	getNextPage := func(ctx context.Context, req sql.ListQueryHistoryRequest) (*sql.ListQueriesResponse, error) {
+		return w.QueryHistory.List(ctx, req)
+	}
+
+	getItems := func(resp *sql.ListQueriesResponse) []sql.QueryInfo {
+		return resp.Res
+	}
+
+	getNextReq := func(resp *sql.ListQueriesResponse) *sql.ListQueryHistoryRequest {
+		if !resp.HasNextPage || resp.NextPageToken == "" {
+			return nil
+		}
+
+		next := request
+		next.PageToken = resp.NextPageToken
+		return &next
+	}
+
+	return listing.NewIterator(&request, getNextPage, getItems, getNextReq)
+}
@@ -0,0 +1,430 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds how far ahead next looks for a fire time, so expressions that
+// never fire (e.g. 30 FEB) don't loop forever.
+const cronSearchYears = 5
+
+var (
+	cronMonthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+
+	// Quartz numbers the days of the week from 1 (SUN) to 7 (SAT)
+	cronDayNames = map[string]int{
+		"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+	}
+)
+
+// cronSchedule is a parsed Quartz cron expression, as used by Databricks job schedules:
+// seconds, minutes, hours, day of month, month, day of week and an optional year.
+type cronSchedule struct {
+	seconds, minutes, hours, months uint64
+	years                           map[int]bool
+
+	// day of month, anyDay is set for * and ?
+	anyDayOfMonth  bool
+	daysOfMonth    uint64
+	lastDayOffsets []int // L and L-n
+	lastWeekday    bool  // LW
+	nearestWeekday []int // nW
+
+	// day of week, as time.Weekday bits
+	anyDayOfWeek bool
+	daysOfWeek   uint64
+	lastOfWeek   uint64      // nL
+	nthOfWeek    map[int]int // n#k, weekday to k
+
+	location *time.Location
+}
+
+// parseCronSchedule parses a Quartz cron expression evaluated in the given timezone.
+func parseCronSchedule(expression string, timezone string) (*cronSchedule, error) {
+	fields := strings.Fields(strings.ToUpper(expression))
+	if len(fields) != 6 && len(fields) != 7 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 6 or 7 fields, got %d", expression, len(fields))
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+		}
+	}
+
+	s := &cronSchedule{location: location, nthOfWeek: map[int]int{}}
+
+	var err error
+	if s.seconds, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid seconds: %v", err)
+	}
+
+	if s.minutes, err = parseCronField(fields[1], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minutes: %v", err)
+	}
+
+	if s.hours, err = parseCronField(fields[2], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hours: %v", err)
+	}
+
+	if err := s.parseDayOfMonth(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %v", err)
+	}
+
+	if s.months, err = parseCronField(fields[4], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid month: %v", err)
+	}
+
+	if err := s.parseDayOfWeek(fields[5]); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %v", err)
+	}
+
+	if !s.anyDayOfMonth && !s.anyDayOfWeek {
+		return nil, fmt.Errorf("invalid cron expression %q: day of month and day of week can't both be set", expression)
+	}
+
+	if len(fields) == 7 && fields[6] != "*" {
+		years, err := parseCronYears(fields[6])
+		if err != nil {
+			return nil, fmt.Errorf("invalid year: %v", err)
+		}
+
+		s.years = years
+	}
+
+	return s, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps into a bitset.
+func parseCronField(field string, min int, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		from, to, step, err := parseCronRange(part, min, max, names)
+		if err != nil {
+			return 0, err
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// parseCronRange parses a single *, ?, value, range or step expression.
+func parseCronRange(part string, min int, max int, names map[string]int) (int, int, int, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepPart)
+		if err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+
+	if rangePart == "*" || rangePart == "?" {
+		return min, max, step, nil
+	}
+
+	fromPart, toPart, isRange := strings.Cut(rangePart, "-")
+	from, err := parseCronValue(fromPart, min, max, names)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	to := from
+	if isRange {
+		to, err = parseCronValue(toPart, min, max, names)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	} else if hasStep {
+		// a/n is shorthand for a-max/n
+		to = max
+	}
+
+	if to < from {
+		return 0, 0, 0, fmt.Errorf("invalid range %q", rangePart)
+	}
+
+	return from, to, step, nil
+}
+
+func parseCronValue(value string, min int, max int, names map[string]int) (int, error) {
+	if v, ok := names[value]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+	}
+
+	return v, nil
+}
+
+func parseCronYears(field string) (map[int]bool, error) {
+	years := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		from, to, step, err := parseCronRange(part, 1970, 2099, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for y := from; y <= to; y += step {
+			years[y] = true
+		}
+	}
+
+	return years, nil
+}
+
+func (s *cronSchedule) parseDayOfMonth(field string) error {
+	if field == "*" || field == "?" {
+		s.anyDayOfMonth = true
+		return nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case part == "LW":
+			s.lastWeekday = true
+		case part == "L":
+			s.lastDayOffsets = append(s.lastDayOffsets, 0)
+		case strings.HasPrefix(part, "L-"):
+			offset, err := strconv.Atoi(part[2:])
+			if err != nil || offset < 0 || offset > 30 {
+				return fmt.Errorf("invalid offset %q", part)
+			}
+
+			s.lastDayOffsets = append(s.lastDayOffsets, offset)
+		case strings.HasSuffix(part, "W"):
+			day, err := parseCronValue(strings.TrimSuffix(part, "W"), 1, 31, nil)
+			if err != nil {
+				return err
+			}
+
+			s.nearestWeekday = append(s.nearestWeekday, day)
+		default:
+			bits, err := parseCronField(part, 1, 31, nil)
+			if err != nil {
+				return err
+			}
+
+			s.daysOfMonth |= bits
+		}
+	}
+
+	return nil
+}
+
+func (s *cronSchedule) parseDayOfWeek(field string) error {
+	if field == "*" || field == "?" {
+		s.anyDayOfWeek = true
+		return nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case part == "L":
+			// L alone is the last day of the week, i.e. Saturday
+			s.daysOfWeek |= 1 << uint(time.Saturday)
+		case strings.HasSuffix(part, "L"):
+			day, err := parseCronValue(strings.TrimSuffix(part, "L"), 1, 7, cronDayNames)
+			if err != nil {
+				return err
+			}
+
+			s.lastOfWeek |= 1 << uint(day-1)
+		case strings.Contains(part, "#"):
+			dayPart, nthPart, _ := strings.Cut(part, "#")
+			day, err := parseCronValue(dayPart, 1, 7, cronDayNames)
+			if err != nil {
+				return err
+			}
+
+			nth, err := strconv.Atoi(nthPart)
+			if err != nil || nth < 1 || nth > 5 {
+				return fmt.Errorf("invalid occurrence %q", part)
+			}
+
+			s.nthOfWeek[day-1] = nth
+		default:
+			bits, err := parseCronField(part, 1, 7, cronDayNames)
+			if err != nil {
+				return err
+			}
+
+			// shift from Quartz (1 = SUN) to time.Weekday (0 = SUN) numbering
+			s.daysOfWeek |= bits >> 1
+		}
+	}
+
+	return nil
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func weekdayOf(year int, month time.Month, day int) time.Weekday {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+}
+
+// nearestWeekdayOf returns the weekday closest to the given day, without leaving the month.
+func nearestWeekdayOf(year int, month time.Month, day int) int {
+	last := daysInMonth(year, month)
+	day = min(day, last)
+
+	switch weekdayOf(year, month, day) {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	default:
+		return day
+	}
+}
+
+func (s *cronSchedule) matchesDayOfMonth(year int, month time.Month, day int) bool {
+	if s.anyDayOfMonth || s.daysOfMonth&(1<<uint(day)) != 0 {
+		return true
+	}
+
+	last := daysInMonth(year, month)
+	for _, offset := range s.lastDayOffsets {
+		if day == last-offset {
+			return true
+		}
+	}
+
+	if s.lastWeekday && day == nearestWeekdayOf(year, month, last) {
+		return true
+	}
+
+	for _, target := range s.nearestWeekday {
+		if target <= last && day == nearestWeekdayOf(year, month, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *cronSchedule) matchesDayOfWeek(year int, month time.Month, day int) bool {
+	if s.anyDayOfWeek {
+		return true
+	}
+
+	weekday := weekdayOf(year, month, day)
+	if s.daysOfWeek&(1<<uint(weekday)) != 0 {
+		return true
+	}
+
+	if s.lastOfWeek&(1<<uint(weekday)) != 0 && day+7 > daysInMonth(year, month) {
+		return true
+	}
+
+	if nth, ok := s.nthOfWeek[int(weekday)]; ok && (day-1)/7+1 == nth {
+		return true
+	}
+
+	return false
+}
+
+func (s *cronSchedule) matchesDay(year int, month time.Month, day int) bool {
+	if s.years != nil && !s.years[year] {
+		return false
+	}
+
+	if s.months&(1<<uint(month)) == 0 {
+		return false
+	}
+
+	return s.matchesDayOfMonth(year, month, day) && s.matchesDayOfWeek(year, month, day)
+}
+
+// next returns the first fire time strictly after the given time. Local times skipped by a
+// daylight saving transition don't fire.
+func (s *cronSchedule) next(after time.Time) (time.Time, bool) {
+	start := after.In(s.location).Truncate(time.Second).Add(time.Second)
+	year, month, day := start.Date()
+	limit := year + cronSearchYears
+
+	for date := time.Date(year, month, day, 0, 0, 0, 0, s.location); date.Year() <= limit; date = date.AddDate(0, 0, 1) {
+		y, m, d := date.Date()
+		if !s.matchesDay(y, m, d) {
+			continue
+		}
+
+		for h := 0; h < 24; h++ {
+			if s.hours&(1<<uint(h)) == 0 {
+				continue
+			}
+
+			for mi := 0; mi < 60; mi++ {
+				if s.minutes&(1<<uint(mi)) == 0 {
+					continue
+				}
+
+				for sec := 0; sec < 60; sec++ {
+					if s.seconds&(1<<uint(sec)) == 0 {
+						continue
+					}
+
+					candidate := time.Date(y, m, d, h, mi, sec, 0, s.location)
+					if candidate.Hour() != h || candidate.Minute() != mi || candidate.Before(start) {
+						continue
+					}
+
+					return candidate, true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// between returns the fire times within [from, to], up to limit.
+func (s *cronSchedule) between(from time.Time, to time.Time, limit int) []time.Time {
+	times := []time.Time{}
+	t := from.Add(-time.Second)
+	for len(times) < limit {
+		next, ok := s.next(t)
+		if !ok || next.After(to) {
+			break
+		}
+
+		t = next
+		if next.Before(from) {
+			continue
+		}
+
+		times = append(times, next)
+	}
+
+	return times
+}
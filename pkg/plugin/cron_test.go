@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"0 0 12 * *",
+		"0 0 25 * * ?",
+		"0 0 12 15 * MON",
+		"0 0 12 ? * FOO",
+		"0 0/0 12 ? * *",
+	}
+
+	for _, expression := range invalid {
+		if _, err := parseCronSchedule(expression, "UTC"); err == nil {
+			t.Errorf("expected error for %q", expression)
+		}
+	}
+
+	if _, err := parseCronSchedule("0 0 12 * * ?", "Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// 2025-01-01 is a Wednesday
+	after := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"0 0 12 * * ?", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0/15 * * * ?", time.Date(2025, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"30 10 8-9 ? * MON-FRI", time.Date(2025, 1, 2, 8, 10, 30, 0, time.UTC)},
+		{"0 0 6 ? * 1", time.Date(2025, 1, 5, 6, 0, 0, 0, time.UTC)},
+		{"0 0 0 L * ?", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 L-2 FEB ?", time.Date(2025, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 LW * ?", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 1W * ?", time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 ? * 6L", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 ? * MON#2", time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 1 1 ? 2027", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := parseCronSchedule(test.expression, "UTC")
+		if err != nil {
+			t.Errorf("%q: %v", test.expression, err)
+			continue
+		}
+
+		next, ok := schedule.next(after)
+		if !ok || !next.Equal(test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.expression, test.expected, next)
+		}
+	}
+
+	t.Run("should never fire on impossible dates", func(t *testing.T) {
+		schedule, err := parseCronSchedule("0 0 0 30 FEB ?", "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := schedule.next(after); ok {
+			t.Error("expected no fire time")
+		}
+	})
+
+	t.Run("should evaluate in the schedule timezone", func(t *testing.T) {
+		schedule, err := parseCronSchedule("0 0 9 * * ?", "America/New_York")
+		if err != nil {
+			t.Skip(err)
+		}
+
+		next, _ := schedule.next(after)
+		if !next.Equal(time.Date(2025, 1, 1, 14, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected fire time: %v", next.UTC())
+		}
+	})
+}
+
+func TestCronScheduleBetween(t *testing.T) {
+	t.Parallel()
+
+	schedule, err := parseCronSchedule("0 0 * * * ?", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := schedule.between(from, from.Add(3*time.Hour), 100)
+	if len(times) != 4 || !times[0].Equal(from) {
+		t.Errorf("expected 4 fire times from %v, got %v", from, times)
+	}
+
+	if times := schedule.between(from, from.Add(3*time.Hour), 2); len(times) != 2 {
+		t.Errorf("expected limit of 2 fire times, got %d", len(times))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/databricks/databricks-sdk-go"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -19,19 +20,41 @@ import (
 var (
 	_ backend.QueryDataHandler      = (*Datasource)(nil)
 	_ backend.CheckHealthHandler    = (*Datasource)(nil)
+	_ backend.StreamHandler         = (*Datasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*Datasource)(nil)
 )
 
 const (
-	resourceTypeJobRuns         = "job_runs"
-	resourceTypePipelines       = "pipelines"
-	resourceTypePipelineUpdates = "pipeline_updates"
+	resourceTypeJobRuns          = "job_runs"
+	resourceTypeJobRunLogs       = "job_run_logs"
+	resourceTypePipelines        = "pipelines"
+	resourceTypePipelineUpdates  = "pipeline_updates"
+	resourceTypeClusters         = "clusters"
+	resourceTypeClusterEvents    = "cluster_events"
+	resourceTypeSQLWarehouses    = "sql_warehouses"
+	resourceTypeQueryHistory     = "query_history"
+	resourceTypeUsage            = "usage"
+	resourceTypeTables           = "tables"
+	resourceTypeTableHistory     = "table_history"
+	resourceTypeServingEndpoints = "serving_endpoints"
+	resourceTypeServingMetrics   = "serving_endpoint_metrics"
+	resourceTypeMlflowRuns       = "mlflow_runs"
+	resourceTypeMlflowHistory    = "mlflow_metric_history"
+	resourceTypeWorkspaces       = "workspaces"
+	resourceTypeAuditEvents      = "audit_events"
 )
 
 // NewDatasource creates a new datasource instance.
 func NewDatasource(_ context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Datasource{
-		settings: settings,
+		settings:         settings,
+		metricsBuffer:    newMetricsBuffer(servingMetricsRetention),
+		runHistory:       newRunHistoryCache(),
+		workspaceClients: newWorkspaceClients(),
+		streams:          newStreamPollers(ctx),
+		cancel:           cancel,
 	}, nil
 }
 
@@ -39,6 +62,19 @@ func NewDatasource(_ context.Context, settings backend.DataSourceInstanceSetting
 // its health and has streaming skills.
 type Datasource struct {
 	settings backend.DataSourceInstanceSettings
+
+	// metricsBuffer keeps the serving endpoint metrics scraped by previous queries
+	metricsBuffer *metricsBuffer
+
+	// runHistory caches the job runs before the query range used for duration baselines
+	runHistory *runHistoryCache
+
+	// workspaceClients caches the account's workspaces and their clients in account mode
+	workspaceClients *workspaceClients
+
+	// streams runs the pollers of the live streams, until the instance is disposed
+	streams *streamPollers
+	cancel  context.CancelFunc
 }
 
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a new instance
@@ -46,6 +82,7 @@ type Datasource struct {
 // be disposed and a new one will be created using NewSampleDatasource factory function.
 func (d *Datasource) Dispose() {
 	// Clean up datasource instance resources.
+	d.cancel()
 }
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -59,6 +96,9 @@ func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataReques
 	// loop over queries and execute them individually.
 	for _, q := range req.Queries {
 		res := d.query(ctx, req.PluginContext, q)
+		for _, frame := range res.Frames {
+			setFieldUnits(frame)
+		}
 
 		// save the response in a hashmap
 		// based on with RefID as identifier
@@ -73,6 +113,10 @@ type queryModel struct {
 	ResourceType   string          `json:"resourceType"`
 	ResourceParams json.RawMessage `json:"resourceParams,omitempty"`
 	Limit          int             `json:"limit"`
+	Transform      *queryTransform `json:"transform,omitempty"`
+
+	// Workspaces selects the workspaces an account-level query fans out to, all by default
+	Workspaces []string `json:"workspaces,omitempty"`
 }
 
 func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) backend.DataResponse {
@@ -83,12 +127,75 @@ func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, quer
 		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("json unmarshal: %v", err.Error()))
 	}
 
+	if qm.Transform != nil {
+		if err := qm.Transform.validate(); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid transform: %v", err))
+		}
+	}
+
+	config, err := models.LoadPluginSettings(d.settings)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("load plugin settings: %v", err))
+	}
+
+	var res backend.DataResponse
+	if isAccountMode(config) && qm.ResourceType != resourceTypeWorkspaces {
+		res = d.queryWorkspaces(ctx, pCtx, query, qm, config)
+	} else {
+		res = d.queryResource(ctx, pCtx, query, qm)
+	}
+
+	if res.Error != nil || qm.Transform == nil {
+		return res
+	}
+
+	frames, err := qm.Transform.apply(res.Frames)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to transform frames: %v", err))
+	}
+
+	res.Frames = frames
+	return res
+}
+
+// queryResource runs a query against the API of its resource type.
+func (d *Datasource) queryResource(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
 	// TODO: See if it makes sense to fetch the results via SQL statement from system.lakeflow and other tables instead (for perf reasons, in certain cases)
 	switch qm.ResourceType {
 	case resourceTypeJobRuns:
 		return d.queryJobRuns(ctx, pCtx, query, qm)
+	case resourceTypeJobRunLogs:
+		return d.queryJobRunLogs(ctx, pCtx, query, qm)
 	case resourceTypePipelines:
 		return d.queryPipelines(ctx, pCtx, query, qm)
+	case resourceTypePipelineUpdates:
+		return d.queryPipelineUpdates(ctx, pCtx, query, qm)
+	case resourceTypeClusters:
+		return d.queryClusters(ctx, pCtx, query, qm)
+	case resourceTypeClusterEvents:
+		return d.queryClusterEvents(ctx, pCtx, query, qm)
+	case resourceTypeSQLWarehouses:
+		return d.queryWarehouses(ctx, pCtx, query, qm)
+	case resourceTypeQueryHistory:
+		return d.queryQueryHistory(ctx, pCtx, query, qm)
+	case resourceTypeUsage:
+		return d.queryUsage(ctx, pCtx, query, qm)
+	case resourceTypeTables:
+		return d.queryTables(ctx, pCtx, query, qm)
+	case resourceTypeTableHistory:
+		return d.queryTableHistory(ctx, pCtx, query, qm)
+	case resourceTypeServingEndpoints:
+		return d.queryServingEndpoints(ctx, pCtx, query, qm)
+	case resourceTypeServingMetrics:
+		return d.queryServingEndpointMetrics(ctx, pCtx, query, qm)
+	case resourceTypeMlflowRuns:
+		return d.queryMlflowRuns(ctx, pCtx, query, qm)
+	case resourceTypeMlflowHistory:
+		return d.queryMlflowMetricHistory(ctx, pCtx, query, qm)
+	case resourceTypeWorkspaces:
+		return d.queryWorkspaceList(ctx, pCtx, query, qm)
+	case resourceTypeAuditEvents:
+		return d.queryAuditEvents(ctx, pCtx, query, qm)
 	default:
 		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown resource kind: %s", qm.ResourceType))
 	}
@@ -114,6 +221,21 @@ func (d *Datasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequ
 		return res, nil
 	}
 
+	if isAccountMode(config) {
+		workspaces, err := d.workspaceClients.workspaces(context.Background(), config, time.Now())
+		if err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprintf("Error: %v", err),
+			}, nil
+		}
+
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusOk,
+			Message: fmt.Sprintf("Data source is working, found %d workspaces", len(workspaces)),
+		}, nil
+	}
+
 	w, _ := d.getDatabricksClient(context.Background(), req.PluginContext)
 	_, err = w.CurrentUser.Me(context.Background())
 
@@ -130,12 +252,23 @@ func (d *Datasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequ
 	}, nil
 }
 
+// getDatabricksClient returns the client of the configured workspace, or in account mode the
+// cached client of the workspace the query was fanned out to.
 func (d *Datasource) getDatabricksClient(ctx context.Context, pCtx backend.PluginContext) (*databricks.WorkspaceClient, error) {
 	config, err := models.LoadPluginSettings(d.settings)
 	if err != nil {
 		return nil, fmt.Errorf("load plugin settings: %v", err)
 	}
 
+	if isAccountMode(config) {
+		entry, ok := workspaceFromContext(ctx)
+		if !ok {
+			return nil, fmt.Errorf("no workspace selected in account mode")
+		}
+
+		return entry.client, nil
+	}
+
 	dbxConfig := databricks.Config{
 		Host:         config.Workspace,
 		ClientID:     config.Secrets.ClientId,
@@ -144,3 +277,17 @@ func (d *Datasource) getDatabricksClient(ctx context.Context, pCtx backend.Plugi
 
 	return databricks.Must(databricks.NewWorkspaceClient(&dbxConfig)), nil
 }
+
+// getWarehouseId returns the SQL warehouse used to query system tables.
+func (d *Datasource) getWarehouseId() (string, error) {
+	config, err := models.LoadPluginSettings(d.settings)
+	if err != nil {
+		return "", fmt.Errorf("load plugin settings: %v", err)
+	}
+
+	if config.WarehouseId == "" {
+		return "", fmt.Errorf("no SQL warehouse configured for this datasource")
+	}
+
+	return config.WarehouseId, nil
+}
@@ -0,0 +1,381 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// logs returns the audit events as log lines, counts the number of events per action
+	auditModeLogs   = "logs"
+	auditModeCounts = "counts"
+)
+
+var auditIntervals = []string{"MINUTE", "HOUR", "DAY", "WEEK"}
+
+type auditEventsParams struct {
+	Mode         string   `json:"mode,omitempty"`
+	ServiceNames []string `json:"serviceNames,omitempty"`
+	ActionNames  []string `json:"actionNames,omitempty"`
+	User         string   `json:"user,omitempty"`
+	Interval     string   `json:"interval,omitempty"`
+
+	// account-level queries are limited to the selected workspaces and counted per workspace
+	workspaceIds []string
+	byWorkspace  bool
+}
+
+type auditEvent struct {
+	Time          time.Time
+	EventID       string
+	WorkspaceID   string
+	ServiceName   string
+	ActionName    string
+	User          string
+	SourceIP      string
+	StatusCode    *int64
+	ErrorMessage  string
+	RequestParams string
+}
+
+func parseAuditEventsParams(query backend.DataQuery, qm queryModel) (auditEventsParams, error) {
+	var params auditEventsParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	switch params.Mode {
+	case "":
+		params.Mode = auditModeLogs
+	case auditModeLogs, auditModeCounts:
+	default:
+		return params, fmt.Errorf("unknown mode: %s", params.Mode)
+	}
+
+	params.Interval = strings.ToUpper(params.Interval)
+	if params.Interval == "" {
+		params.Interval = auditIntervalFor(sampleInterval(query))
+	}
+
+	if !slices.Contains(auditIntervals, params.Interval) {
+		return params, fmt.Errorf("unsupported interval: %s", params.Interval)
+	}
+
+	return params, nil
+}
+
+// auditIntervalFor picks the finest bucket that is at least as coarse as the dashboard
+// interval.
+func auditIntervalFor(step time.Duration) string {
+	switch {
+	case step <= time.Minute:
+		return "MINUTE"
+	case step <= time.Hour:
+		return "HOUR"
+	case step <= 24*time.Hour:
+		return "DAY"
+	default:
+		return "WEEK"
+	}
+}
+
+// auditFilter builds the WHERE clause shared by both modes. The event date is filtered as
+// well, since the table is partitioned by it. Service and action names and the user pattern
+// are passed as parameters.
+func auditFilter(params auditEventsParams, query backend.DataQuery) (string, []sql.StatementParameterListItem) {
+	conditions := []string{
+		"event_date >= CAST(:from AS DATE)",
+		"event_time >= :from",
+		"event_time < :to",
+	}
+	parameters := []sql.StatementParameterListItem{
+		timestampParameter("from", query.TimeRange.From),
+		timestampParameter("to", query.TimeRange.To),
+	}
+
+	in := func(column string, prefix string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+
+		names := []string{}
+		for i, value := range values {
+			name := fmt.Sprintf("%s%d", prefix, i)
+			names = append(names, ":"+name)
+			parameters = append(parameters, stringParameter(name, value))
+		}
+
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(names, ", ")))
+	}
+
+	in("service_name", "service", params.ServiceNames)
+	in("action_name", "action", params.ActionNames)
+
+	if params.User != "" {
+		conditions = append(conditions, `user_identity.email LIKE :user ESCAPE '\\'`)
+		parameters = append(parameters, stringParameter("user", likePattern(params.User)))
+	}
+
+	if filter, workspaceParameters := workspaceIdFilter("workspace_id", params.workspaceIds); filter != "" {
+		conditions = append(conditions, filter)
+		parameters = append(parameters, workspaceParameters...)
+	}
+
+	return strings.Join(conditions, "\n  AND "), parameters
+}
+
+func buildAuditEventsStatement(params auditEventsParams, query backend.DataQuery, limit int) (string, []sql.StatementParameterListItem) {
+	filter, parameters := auditFilter(params, query)
+
+	statement := fmt.Sprintf(`SELECT event_time, event_id, CAST(workspace_id AS STRING) AS workspace_id,
+  service_name, action_name, user_identity.email AS user_email, source_ip_address,
+  response.status_code AS status_code, response.error_message AS error_message,
+  to_json(request_params) AS request_params
+FROM system.access.audit
+WHERE %s
+ORDER BY event_time DESC
+LIMIT %d`, filter, limit)
+
+	return statement, parameters
+}
+
+func buildAuditCountsStatement(params auditEventsParams, query backend.DataQuery) (string, []sql.StatementParameterListItem) {
+	filter, parameters := auditFilter(params, query)
+
+	columns, groupBy := "service_name, action_name", "1, 2, 3"
+	if params.byWorkspace {
+		columns, groupBy = columns+", CAST(workspace_id AS STRING) AS workspace_id", groupBy+", 4"
+	}
+
+	statement := fmt.Sprintf(`SELECT date_trunc('%s', event_time) AS time, %s,
+  COUNT(*) AS events
+FROM system.access.audit
+WHERE %s
+GROUP BY %s
+ORDER BY 1`, params.Interval, columns, filter, groupBy)
+
+	return statement, parameters
+}
+
+func parseAuditEvents(result *statementResult) ([]auditEvent, error) {
+	events := []auditEvent{}
+	for _, row := range result.Rows {
+		t, err := parseStatementTime(result.value(row, "event_time"))
+		if err != nil {
+			return nil, err
+		}
+
+		if t == nil {
+			continue
+		}
+
+		event := auditEvent{
+			Time:          *t,
+			EventID:       result.value(row, "event_id"),
+			WorkspaceID:   result.value(row, "workspace_id"),
+			ServiceName:   result.value(row, "service_name"),
+			ActionName:    result.value(row, "action_name"),
+			User:          result.value(row, "user_email"),
+			SourceIP:      result.value(row, "source_ip_address"),
+			ErrorMessage:  result.value(row, "error_message"),
+			RequestParams: result.value(row, "request_params"),
+		}
+
+		if code := result.value(row, "status_code"); code != "" {
+			statusCode, err := strconv.ParseInt(code, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code: %s", code)
+			}
+
+			event.StatusCode = &statusCode
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// auditLogEntry describes an event as a log line, e.g. "jobs.changeJobAcl by alice@example.com
+// {"job_id":"42"}". Failed requests are logged as errors.
+func auditLogEntry(event auditEvent) logEntry {
+	body := event.ServiceName + "." + event.ActionName
+	if event.User != "" {
+		body += " by " + event.User
+	}
+
+	if event.RequestParams != "" && event.RequestParams != "{}" {
+		body += " " + event.RequestParams
+	}
+
+	level := "info"
+	if event.ErrorMessage != "" || (event.StatusCode != nil && *event.StatusCode >= 400) {
+		level = "error"
+	}
+
+	if event.ErrorMessage != "" {
+		body += ": " + event.ErrorMessage
+	}
+
+	labels := map[string]string{
+		"service_name": event.ServiceName,
+		"action_name":  event.ActionName,
+		"event_id":     event.EventID,
+	}
+
+	for key, value := range map[string]string{
+		"user":         event.User,
+		"workspace_id": event.WorkspaceID,
+		"source_ip":    event.SourceIP,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+
+	if event.StatusCode != nil {
+		labels["status_code"] = strconv.FormatInt(*event.StatusCode, 10)
+	}
+
+	return logEntry{Time: event.Time, Level: level, Body: body, Labels: labels}
+}
+
+// buildAuditCountsFrames converts the counts into one time series frame per action, labelled
+// with the service and action name, and with the workspace id when counted per workspace.
+func buildAuditCountsFrames(result *statementResult) ([]*data.Frame, error) {
+	frames := []*data.Frame{}
+	byAction := map[string]*data.Frame{}
+
+	for _, row := range result.Rows {
+		t, err := parseStatementTime(result.value(row, "time"))
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := strconv.ParseInt(result.value(row, "events"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event count: %s", result.value(row, "events"))
+		}
+
+		if t == nil {
+			continue
+		}
+
+		labels := data.Labels{
+			"service_name": result.value(row, "service_name"),
+			"action_name":  result.value(row, "action_name"),
+		}
+
+		if workspaceId := result.value(row, "workspace_id"); workspaceId != "" {
+			labels[workspaceLabel] = workspaceId
+		}
+
+		key := labels.String()
+		frame, ok := byAction[key]
+		if !ok {
+			frame = data.NewFrame("Databricks Audit Events",
+				data.NewField("Time", nil, []time.Time{}),
+				data.NewField("Events", labels, []int64{}),
+			)
+
+			byAction[key] = frame
+			frames = append(frames, frame)
+		}
+
+		frame.AppendRow(*t, events)
+	}
+
+	return frames, nil
+}
+
+func (d *Datasource) queryAuditEvents(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseAuditEventsParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	scope, accountLevel := workspaceScopeFromContext(ctx)
+	if accountLevel {
+		params.workspaceIds = scope.ids
+		params.byWorkspace = true
+	}
+
+	warehouseId, err := d.getWarehouseId()
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	if params.Mode == auditModeCounts {
+		statement, parameters := buildAuditCountsStatement(params, query)
+		result, err := executeStatement(ctx, w, warehouseId, statement, parameters)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to query audit events: %v", err))
+		}
+
+		frames, err := buildAuditCountsFrames(result)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to build audit event frames: %v", err))
+		}
+
+		if accountLevel {
+			frames = scope.nameWorkspaces(frames)
+		}
+
+		return backend.DataResponse{
+			Frames: withExecutedQuery(frames, describeStatement(statement, parameters)),
+		}
+	}
+
+	statement, parameters := buildAuditEventsStatement(params, query, qm.Limit)
+	result, err := executeStatement(ctx, w, warehouseId, statement, parameters)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to query audit events: %v", err))
+	}
+
+	events, err := parseAuditEvents(result)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to parse audit events: %v", err))
+	}
+
+	entries := []logEntry{}
+	for _, event := range events {
+		entry := auditLogEntry(event)
+		if accountLevel {
+			entry.Labels[workspaceLabel] = scope.name(event.WorkspaceID)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	frame, err := buildLogsFrame("Databricks Audit Events", entries)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to build logs frame: %v", err))
+	}
+
+	if qm.Limit > 0 && len(events) == qm.Limit {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the latest %d events are shown, narrow the filters or time range to see the rest", qm.Limit),
+		})
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeStatement(statement, parameters)),
+	}
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestParseAuditEventsParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should default to logs and derive interval from the query", func(t *testing.T) {
+		params, err := parseAuditEventsParams(backend.DataQuery{Interval: 5 * time.Minute}, queryModel{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if params.Mode != auditModeLogs || params.Interval != "HOUR" {
+			t.Errorf("unexpected defaults: %+v", params)
+		}
+	})
+
+	t.Run("should reject unknown mode", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"mode": "table"}`)}
+		if _, err := parseAuditEventsParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("should reject unknown interval", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"interval": "second"}`)}
+		if _, err := parseAuditEventsParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBuildAuditEventsStatement(t *testing.T) {
+	t.Parallel()
+
+	params := auditEventsParams{
+		ServiceNames: []string{"jobs", "accounts"},
+		ActionNames:  []string{"changeJobAcl"},
+		User:         "*@example.com",
+	}
+	statement, parameters := buildAuditEventsStatement(params, backend.DataQuery{}, 100)
+
+	for _, want := range []string{
+		"service_name IN (:service0, :service1)",
+		"action_name IN (:action0)",
+		`user_identity.email LIKE :user ESCAPE '\\'`,
+		"event_date >= CAST(:from AS DATE)",
+		"LIMIT 100",
+	} {
+		if !strings.Contains(statement, want) {
+			t.Errorf("expected %q in statement: %s", want, statement)
+		}
+	}
+
+	if len(parameters) != 6 || parameters[5].Name != "user" || parameters[5].Value != "%@example.com" {
+		t.Errorf("unexpected parameters: %+v", parameters)
+	}
+
+	statement, parameters = buildAuditCountsStatement(auditEventsParams{Interval: "DAY"}, backend.DataQuery{})
+	if !strings.Contains(statement, "date_trunc('DAY', event_time)") || strings.Contains(statement, "service_name IN") || len(parameters) != 2 {
+		t.Errorf("unexpected counts statement: %s", statement)
+	}
+
+	statement, parameters = buildAuditCountsStatement(auditEventsParams{Interval: "DAY", workspaceIds: []string{"1"}, byWorkspace: true}, backend.DataQuery{})
+	for _, want := range []string{"CAST(workspace_id AS STRING) AS workspace_id", "CAST(workspace_id AS STRING) IN (:workspace0)", "GROUP BY 1, 2, 3, 4"} {
+		if !strings.Contains(statement, want) {
+			t.Errorf("expected %q in statement: %s", want, statement)
+		}
+	}
+
+	if len(parameters) != 3 || parameters[2].Value != "1" {
+		t.Errorf("unexpected parameters: %+v", parameters)
+	}
+}
+
+func TestAuditLogEntries(t *testing.T) {
+	t.Parallel()
+
+	result := &statementResult{
+		Columns: []sql.ColumnInfo{
+			{Name: "event_time"}, {Name: "event_id"}, {Name: "workspace_id"}, {Name: "service_name"}, {Name: "action_name"},
+			{Name: "user_email"}, {Name: "source_ip_address"}, {Name: "status_code"}, {Name: "error_message"}, {Name: "request_params"},
+		},
+		Rows: [][]string{
+			{"2025-01-01T10:00:00.000Z", "e1", "42", "jobs", "changeJobAcl", "alice@example.com", "10.0.0.1", "200", "", `{"job_id":"7"}`},
+			{"2025-01-01T11:00:00.000Z", "e2", "42", "accounts", "tokenLogin", "", "", "403", "forbidden", "{}"},
+		},
+	}
+
+	events, err := parseAuditEvents(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || *events[1].StatusCode != 403 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	entry := auditLogEntry(events[0])
+	if entry.Level != "info" || entry.Body != `jobs.changeJobAcl by alice@example.com {"job_id":"7"}` || entry.Labels["user"] != "alice@example.com" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	entry = auditLogEntry(events[1])
+	if entry.Level != "error" || entry.Body != "accounts.tokenLogin: forbidden" || entry.Labels["status_code"] != "403" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	if _, ok := entry.Labels["user"]; ok {
+		t.Error("expected no user label for an anonymous event")
+	}
+}
+
+func TestBuildAuditCountsFrames(t *testing.T) {
+	t.Parallel()
+
+	result := &statementResult{
+		Columns: []sql.ColumnInfo{{Name: "time"}, {Name: "service_name"}, {Name: "action_name"}, {Name: "events"}},
+		Rows: [][]string{
+			{"2025-01-01T00:00:00.000Z", "jobs", "changeJobAcl", "3"},
+			{"2025-01-01T00:00:00.000Z", "accounts", "tokenLogin", "10"},
+			{"2025-01-02T00:00:00.000Z", "jobs", "changeJobAcl", "1"},
+		},
+	}
+
+	frames, err := buildAuditCountsFrames(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 2 || frames[0].Rows() != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+
+	if labels := frames[0].Fields[1].Labels; labels["service_name"] != "jobs" || labels["action_name"] != "changeJobAcl" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+}
@@ -0,0 +1,284 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/compute"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type clusterEventParams struct {
+	ClusterID  string   `json:"clusterId,omitempty"`
+	ClusterIDs []string `json:"clusterIds,omitempty"`
+	JobID      string   `json:"jobId,omitempty"`
+	EventTypes []string `json:"eventTypes,omitempty"`
+}
+
+func parseClusterEventParams(_ backend.DataQuery, qm queryModel) (clusterEventParams, error) {
+	var params clusterEventParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	if params.ClusterID == "" && len(params.ClusterIDs) == 0 && params.JobID == "" {
+		return params, fmt.Errorf("one of clusterId, clusterIds or jobId is required")
+	}
+
+	if params.JobID != "" {
+		if _, err := strconv.ParseInt(params.JobID, 10, 64); err != nil {
+			return params, fmt.Errorf("invalid job id: %s", params.JobID)
+		}
+	}
+
+	return params, nil
+}
+
+func buildClusterEventsRequest(params clusterEventParams, clusterId string, query backend.DataQuery) (compute.GetEvents, error) {
+	req := compute.GetEvents{
+		ClusterId: clusterId,
+		Limit:     500, // 500 is the max limit for this API - per page
+		Order:     compute.GetEventsOrderAsc,
+	}
+
+	// apply time range filter, if set
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		req.StartTime = query.TimeRange.From.UnixMilli()
+		req.EndTime = query.TimeRange.To.UnixMilli()
+	}
+
+	for _, eventType := range params.EventTypes {
+		var t compute.EventType
+		if err := t.Set(strings.ToUpper(eventType)); err != nil {
+			return req, err
+		}
+
+		req.EventTypes = append(req.EventTypes, t)
+	}
+
+	return req, nil
+}
+
+// clusterIdsFromRuns returns the distinct clusters used by the given runs and their tasks.
+func clusterIdsFromRuns(runs []jobs.BaseRun) []string {
+	ids := []string{}
+	add := func(instance *jobs.ClusterInstance) {
+		if instance != nil && instance.ClusterId != "" && !slices.Contains(ids, instance.ClusterId) {
+			ids = append(ids, instance.ClusterId)
+		}
+	}
+
+	for _, run := range runs {
+		add(run.ClusterInstance)
+		for _, task := range run.Tasks {
+			add(task.ClusterInstance)
+		}
+	}
+
+	return ids
+}
+
+// describeClusterEvent renders the event details relevant to troubleshooting as a single line.
+func describeClusterEvent(event compute.ClusterEvent) string {
+	details := event.Details
+	if details == nil {
+		return ""
+	}
+
+	parts := []string{}
+	if details.Cause != "" {
+		parts = append(parts, fmt.Sprintf("cause: %s", details.Cause))
+	}
+
+	if details.PreviousClusterSize != nil || details.ClusterSize != nil {
+		parts = append(parts, fmt.Sprintf("size: %s -> %s", describeClusterSize(details.PreviousClusterSize), describeClusterSize(details.ClusterSize)))
+	}
+
+	if details.TargetNumWorkers != 0 || details.CurrentNumWorkers != 0 {
+		parts = append(parts, fmt.Sprintf("workers: %d/%d", details.CurrentNumWorkers, details.TargetNumWorkers))
+	}
+
+	if details.Reason != nil && details.Reason.Code != "" {
+		parts = append(parts, fmt.Sprintf("reason: %s", details.Reason.Code))
+	}
+
+	if details.DriverStateMessage != "" {
+		parts = append(parts, fmt.Sprintf("driver: %s", details.DriverStateMessage))
+	}
+
+	if details.DidNotExpandReason != "" {
+		parts = append(parts, fmt.Sprintf("did not expand: %s", details.DidNotExpandReason))
+	}
+
+	if details.InitScripts != nil {
+		for _, script := range slices.Concat(details.InitScripts.Global, details.InitScripts.Cluster) {
+			execution := script.ExecutionDetails
+			if execution == nil || execution.Status == compute.InitScriptExecutionDetailsStatusSucceeded {
+				continue
+			}
+
+			parts = append(parts, fmt.Sprintf("init script %s: %s", execution.Status, execution.ErrorMessage))
+		}
+	}
+
+	if details.InstanceId != "" {
+		parts = append(parts, fmt.Sprintf("instance: %s", details.InstanceId))
+	}
+
+	if details.User != "" {
+		parts = append(parts, fmt.Sprintf("user: %s", details.User))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func describeClusterSize(size *compute.ClusterSize) string {
+	switch {
+	case size == nil:
+		return "?"
+	case size.Autoscale != nil:
+		return fmt.Sprintf("%d-%d", size.Autoscale.MinWorkers, size.Autoscale.MaxWorkers)
+	default:
+		return fmt.Sprintf("%d", size.NumWorkers)
+	}
+}
+
+// mergeClusterEvents orders the events of multiple clusters by time and keeps the
+// first limit of them, reporting whether any were dropped.
+func mergeClusterEvents(events []compute.ClusterEvent, limit int) ([]compute.ClusterEvent, bool) {
+	slices.SortStableFunc(events, func(i, j compute.ClusterEvent) int {
+		return cmp.Compare(i.Timestamp, j.Timestamp)
+	})
+
+	if len(events) > limit {
+		return events[:limit], true
+	}
+
+	return events, false
+}
+
+func buildClusterEventsFrame(events []compute.ClusterEvent, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Cluster Events",
+		data.NewField("Time", nil, []time.Time{}),
+		withLinks(data.NewField("Cluster ID", nil, []string{}), clusterLink(host)),
+		data.NewField("Event Type", nil, []string{}),
+		data.NewField("Current Workers", nil, []*int64{}),
+		data.NewField("Target Workers", nil, []*int64{}),
+		data.NewField("Details", nil, []string{}),
+	)
+
+	// sort results ascending by Timestamp, events of multiple clusters are interleaved
+	slices.SortStableFunc(events, func(i, j compute.ClusterEvent) int {
+		return cmp.Compare(i.Timestamp, j.Timestamp)
+	})
+
+	for _, event := range events {
+		var currentWorkers, targetWorkers *int64
+		if event.Details != nil && (event.Details.CurrentNumWorkers != 0 || event.Details.TargetNumWorkers != 0) {
+			currentWorkers = optionalInt64(int64(event.Details.CurrentNumWorkers))
+			targetWorkers = optionalInt64(int64(event.Details.TargetNumWorkers))
+		}
+
+		frame.AppendRow(
+			time.UnixMilli(event.Timestamp),
+			event.ClusterId,
+			string(event.Type),
+			currentWorkers,
+			targetWorkers,
+			describeClusterEvent(event),
+		)
+	}
+
+	return frame
+}
+
+// resolveEventClusterIds returns the clusters selected by the query, including the
+// clusters used by runs of the selected job within the dashboard time range.
+func resolveEventClusterIds(ctx context.Context, w *databricks.WorkspaceClient, params clusterEventParams, query backend.DataQuery, maxRuns int) ([]string, error) {
+	ids := slices.Clone(params.ClusterIDs)
+	if params.ClusterID != "" && !slices.Contains(ids, params.ClusterID) {
+		ids = append(ids, params.ClusterID)
+	}
+
+	if params.JobID == "" {
+		return ids, nil
+	}
+
+	request, err := buildListRunsRequest(jobRunParams{JobID: params.JobID}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ExpandTasks = true
+	runs, err := fetchJobRuns(ctx, &workspaceClientWrapper{client: w}, request, maxRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range clusterIdsFromRuns(runs) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (d *Datasource) queryClusterEvents(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseClusterEventParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	clusterIds, err := resolveEventClusterIds(ctx, w, params, query, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to resolve job clusters: %v", err))
+	}
+
+	// every cluster is read up to the limit, so a busy cluster does not hide the events of the others
+	events := []compute.ClusterEvent{}
+	executed := []string{}
+	for _, clusterId := range clusterIds {
+		request, err := buildClusterEventsRequest(params, clusterId, query)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build request: %v", err))
+		}
+
+		executed = append(executed, describeRequest("POST", "/api/2.1/clusters/events", request))
+		clusterEvents, err := fetchWithLimit(ctx, w.Clusters.Events(ctx, request), qm.Limit)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list cluster events: %v", err))
+		}
+
+		events = append(events, clusterEvents...)
+	}
+
+	events, truncated := mergeClusterEvents(events, qm.Limit)
+	frame := buildClusterEventsFrame(events, w.Config.Host)
+	if truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the first %d events of the selected clusters are shown, increase Max Results or narrow the time range", qm.Limit),
+		})
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, executed...),
+	}
+}
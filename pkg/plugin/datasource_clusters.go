@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/compute"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type clusterParams struct {
+	States         []string `json:"states,omitempty"`
+	ClusterSources []string `json:"clusterSources,omitempty"`
+}
+
+func parseClusterParams(_ backend.DataQuery, qm queryModel) (clusterParams, error) {
+	var params clusterParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	return params, nil
+}
+
+func buildListClustersRequest(params clusterParams, _ backend.DataQuery) (compute.ListClustersRequest, error) {
+	req := compute.ListClustersRequest{
+		PageSize: 100,
+	}
+
+	if len(params.States) == 0 && len(params.ClusterSources) == 0 {
+		return req, nil
+	}
+
+	filter := &compute.ListClustersFilterBy{}
+	for _, state := range params.States {
+		var s compute.State
+		if err := s.Set(strings.ToUpper(state)); err != nil {
+			return req, err
+		}
+
+		filter.ClusterStates = append(filter.ClusterStates, s)
+	}
+
+	for _, source := range params.ClusterSources {
+		var s compute.ClusterSource
+		if err := s.Set(strings.ToUpper(source)); err != nil {
+			return req, err
+		}
+
+		filter.ClusterSources = append(filter.ClusterSources, s)
+	}
+
+	req.FilterBy = filter
+	return req, nil
+}
+
+// clusterURL returns the link to the cluster configuration page in the workspace UI.
+func clusterURL(host string, clusterId string) string {
+	return fmt.Sprintf("%s/#setting/clusters/%s/configuration", strings.TrimSuffix(host, "/"), clusterId)
+}
+
+// clusterLastActivity returns the most recent lifecycle timestamp known for the cluster,
+// since the clusters API doesn't expose a dedicated last activity time.
+func clusterLastActivity(cluster compute.ClusterDetails) *time.Time {
+	last := max(cluster.StartTime, cluster.LastRestartedTime, cluster.TerminatedTime)
+	return optionalUnixMilli(last)
+}
+
+// clusterUptime returns how long a running cluster has been up since its last (re)start.
+func clusterUptime(cluster compute.ClusterDetails, now time.Time) int64 {
+	switch cluster.State {
+	case compute.StateRunning, compute.StateResizing:
+	default:
+		return 0
+	}
+
+	started := max(cluster.StartTime, cluster.LastRestartedTime)
+	if started == 0 {
+		return 0
+	}
+
+	return max(now.UnixMilli()-started, 0)
+}
+
+func buildClustersFrame(clusters []compute.ClusterDetails, host string, now time.Time) *data.Frame {
+	frame := data.NewFrame("Databricks Clusters",
+		withLinks(data.NewField("Cluster ID", nil, []string{}), clusterLink(host)),
+		data.NewField("Cluster Name", nil, []string{}),
+		data.NewField("State", nil, []string{}),
+		data.NewField("Cluster Source", nil, []string{}),
+		data.NewField("Spark Version", nil, []string{}),
+		data.NewField("Node Type", nil, []string{}),
+		data.NewField("Driver Node Type", nil, []string{}),
+		data.NewField("Workers", nil, []int64{}),
+		data.NewField("Autoscale Min Workers", nil, []*int64{}),
+		data.NewField("Autoscale Max Workers", nil, []*int64{}),
+		data.NewField("Auto Termination (minutes)", nil, []int64{}),
+		data.NewField("Creator", nil, []string{}),
+		data.NewField("Start Time", nil, []*time.Time{}),
+		data.NewField("Uptime (milliseconds)", nil, []int64{}),
+		data.NewField("Last Activity", nil, []*time.Time{}),
+		data.NewField("Termination Reason", nil, []string{}),
+		data.NewField("Cluster URL", nil, []string{}),
+	)
+
+	for _, cluster := range clusters {
+		var minWorkers, maxWorkers *int64
+		if cluster.Autoscale != nil {
+			minWorkers = optionalInt64(int64(cluster.Autoscale.MinWorkers))
+			maxWorkers = optionalInt64(int64(cluster.Autoscale.MaxWorkers))
+		}
+
+		var terminationReason string
+		if cluster.TerminationReason != nil {
+			terminationReason = string(cluster.TerminationReason.Code)
+		}
+
+		frame.AppendRow(
+			cluster.ClusterId,
+			cluster.ClusterName,
+			string(cluster.State),
+			string(cluster.ClusterSource),
+			cluster.SparkVersion,
+			cluster.NodeTypeId,
+			cluster.DriverNodeTypeId,
+			int64(cluster.NumWorkers),
+			minWorkers,
+			maxWorkers,
+			int64(cluster.AutoterminationMinutes),
+			cluster.CreatorUserName,
+			optionalUnixMilli(cluster.StartTime),
+			clusterUptime(cluster, now),
+			clusterLastActivity(cluster),
+			terminationReason,
+			clusterURL(host, cluster.ClusterId),
+		)
+	}
+
+	return frame
+}
+
+func (d *Datasource) queryClusters(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseClusterParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request, err := buildListClustersRequest(params, query)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build request: %v", err))
+	}
+
+	clusters, err := fetchWithLimit(ctx, w.Clusters.List(ctx, request), qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list clusters: %v", err))
+	}
+
+	frame := buildClustersFrame(clusters, w.Config.Host, time.Now())
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeRequest("GET", "/api/2.1/clusters/list", request)),
+	}
+}
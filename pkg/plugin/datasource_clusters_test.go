@@ -0,0 +1,216 @@
+package plugin
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/compute"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestBuildListClustersRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not set filter when no params are used", func(t *testing.T) {
+		req, err := buildListClustersRequest(clusterParams{}, backend.DataQuery{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if req.FilterBy != nil {
+			t.Error("expected filter to be empty")
+		}
+	})
+
+	t.Run("should set state and source filters", func(t *testing.T) {
+		params := clusterParams{
+			States:         []string{"running", "TERMINATED"},
+			ClusterSources: []string{"UI"},
+		}
+
+		req, err := buildListClustersRequest(params, backend.DataQuery{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if len(req.FilterBy.ClusterStates) != 2 || req.FilterBy.ClusterStates[0] != compute.StateRunning {
+			t.Errorf("unexpected cluster states: %v", req.FilterBy.ClusterStates)
+		}
+
+		if len(req.FilterBy.ClusterSources) != 1 || req.FilterBy.ClusterSources[0] != compute.ClusterSourceUi {
+			t.Errorf("unexpected cluster sources: %v", req.FilterBy.ClusterSources)
+		}
+	})
+
+	t.Run("should return error when state is invalid", func(t *testing.T) {
+		_, err := buildListClustersRequest(clusterParams{States: []string{"invalid"}}, backend.DataQuery{})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBuildClustersFrame(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	clusters := []compute.ClusterDetails{
+		{
+			ClusterId:   "0101-abc",
+			ClusterName: "shared",
+			State:       compute.StateRunning,
+			StartTime:   now.Add(-time.Hour).UnixMilli(),
+			Autoscale:   &compute.AutoScale{MinWorkers: 1, MaxWorkers: 4},
+		},
+		{
+			ClusterId:         "0101-def",
+			State:             compute.StateTerminated,
+			StartTime:         now.Add(-2 * time.Hour).UnixMilli(),
+			TerminatedTime:    now.Add(-time.Hour).UnixMilli(),
+			TerminationReason: &compute.TerminationReason{Code: compute.TerminationReasonCodeInactivity},
+		},
+	}
+
+	frame := buildClustersFrame(clusters, testHost, now)
+	if frame.Rows() != 2 {
+		t.Fatalf("expected 2 rows, got %d", frame.Rows())
+	}
+
+	uptime, _ := frame.FieldByName("Uptime (milliseconds)")
+	if uptime.At(0).(int64) != time.Hour.Milliseconds() {
+		t.Errorf("expected uptime of one hour, got %v", uptime.At(0))
+	}
+
+	if uptime.At(1).(int64) != 0 {
+		t.Errorf("expected no uptime for terminated cluster, got %v", uptime.At(1))
+	}
+
+	maxWorkers, _ := frame.FieldByName("Autoscale Max Workers")
+	if maxWorkers.At(1).(*int64) != nil {
+		t.Error("expected autoscale to be null for fixed size cluster")
+	}
+
+	url, _ := frame.FieldByName("Cluster URL")
+	if url.At(0).(string) != "https://example.com/#setting/clusters/0101-abc/configuration" {
+		t.Errorf("unexpected cluster url: %v", url.At(0))
+	}
+
+	if _, err := frame.MarshalJSON(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClusterIdsFromRuns(t *testing.T) {
+	t.Parallel()
+
+	runs := []jobs.BaseRun{
+		{
+			ClusterInstance: &jobs.ClusterInstance{ClusterId: "a"},
+		},
+		{
+			Tasks: []jobs.RunTask{
+				{ClusterInstance: &jobs.ClusterInstance{ClusterId: "b"}},
+				{ClusterInstance: &jobs.ClusterInstance{ClusterId: "a"}},
+				{},
+			},
+		},
+	}
+
+	ids := clusterIdsFromRuns(runs)
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("expected distinct cluster ids [a b], got %v", ids)
+	}
+}
+
+func TestBuildClusterEventsFrame(t *testing.T) {
+	t.Parallel()
+
+	events := []compute.ClusterEvent{
+		{
+			ClusterId: "b",
+			Timestamp: 2000,
+			Type:      compute.EventTypeResizing,
+			Details: &compute.EventDetails{
+				Cause:               compute.EventDetailsCauseAutoscale,
+				PreviousClusterSize: &compute.ClusterSize{NumWorkers: 2},
+				ClusterSize:         &compute.ClusterSize{NumWorkers: 4},
+			},
+		},
+		{
+			ClusterId: "a",
+			Timestamp: 1000,
+			Type:      compute.EventTypeDriverNotResponding,
+			Details:   &compute.EventDetails{DriverStateMessage: "GC overhead"},
+		},
+	}
+
+	frame := buildClusterEventsFrame(events, testHost)
+	if frame.Rows() != 2 {
+		t.Fatalf("expected 2 rows, got %d", frame.Rows())
+	}
+
+	details, _ := frame.FieldByName("Details")
+	if details.At(0).(string) != "driver: GC overhead" {
+		t.Errorf("unexpected details: %v", details.At(0))
+	}
+
+	if details.At(1).(string) != "cause: AUTOSCALE, size: 2 -> 4" {
+		t.Errorf("unexpected details: %v", details.At(1))
+	}
+}
+
+func TestMergeClusterEvents(t *testing.T) {
+	t.Parallel()
+
+	// every cluster is read up to the limit, the busy cluster "a" must not hide the events of "b"
+	events := []compute.ClusterEvent{
+		{ClusterId: "a", Timestamp: 1000},
+		{ClusterId: "a", Timestamp: 3000},
+		{ClusterId: "a", Timestamp: 4000},
+		{ClusterId: "b", Timestamp: 2000},
+	}
+
+	t.Run("should keep the earliest events across clusters", func(t *testing.T) {
+		merged, truncated := mergeClusterEvents(slices.Clone(events), 3)
+		if !truncated {
+			t.Error("expected the events to be truncated")
+		}
+
+		ids := []string{}
+		for _, event := range merged {
+			ids = append(ids, event.ClusterId)
+		}
+
+		if !slices.Equal(ids, []string{"a", "b", "a"}) {
+			t.Errorf("unexpected events: %v", ids)
+		}
+	})
+
+	t.Run("should not truncate events within the limit", func(t *testing.T) {
+		merged, truncated := mergeClusterEvents(slices.Clone(events), 4)
+		if truncated || len(merged) != 4 {
+			t.Errorf("expected all 4 events, got %d (truncated %v)", len(merged), truncated)
+		}
+	})
+}
+
+func TestParseClusterEventParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should accept a job id", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"jobId": "42"}`)}
+		if _, err := parseClusterEventParams(backend.DataQuery{}, qm); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should reject an invalid job id", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"jobId": "etl"}`)}
+		if _, err := parseClusterEventParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
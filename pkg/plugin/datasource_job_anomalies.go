@@ -0,0 +1,335 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultBaselineRuns = 20
+
+	// a baseline needs at least this many earlier successful runs
+	minBaselineRuns = 3
+
+	// runHistoryTTL is how long the successful runs before the time range are cached per job
+	runHistoryTTL = 15 * time.Minute
+
+	// runHistoryMaxJobs bounds the number of jobs a run history cache holds
+	runHistoryMaxJobs = 1000
+
+	// scale factor making the MAD a consistent estimator of the standard deviation
+	madScale = 1.4826
+)
+
+type runAnomaly struct {
+	Baseline float64
+	Score    *float64
+	Slower   float64
+}
+
+type runHistoryEntry struct {
+	until     time.Time
+	fetchedAt time.Time
+	count     int
+	runs      []jobs.BaseRun
+}
+
+// runHistoryCache keeps the most recent successful runs of each job before a point in time,
+// so the duration baselines don't have to be fetched on every dashboard refresh.
+type runHistoryCache struct {
+	mu      sync.Mutex
+	entries map[int64]runHistoryEntry
+}
+
+func newRunHistoryCache() *runHistoryCache {
+	return &runHistoryCache{entries: map[int64]runHistoryEntry{}}
+}
+
+// get returns the cached runs of a job, as long as they are fresh and were fetched before a
+// point in time at most the TTL earlier than until (e.g. relative time ranges). The runs
+// between the cached and the requested point in time are not part of the entry, the caller
+// has to fetch them and extend the entry.
+func (c *runHistoryCache) get(jobId int64, until time.Time, count int, now time.Time) (runHistoryEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[jobId]
+	if !ok || entry.count < count || now.Sub(entry.fetchedAt) > runHistoryTTL ||
+		entry.until.After(until) || until.Sub(entry.until) > runHistoryTTL {
+		return runHistoryEntry{}, false
+	}
+
+	return entry, true
+}
+
+// extend moves the point in time of a cached entry forward, with the runs fetched since,
+// keeping the time it was fetched so it still expires.
+func (c *runHistoryCache) extend(jobId int64, until time.Time, runs []jobs.BaseRun) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[jobId]; ok && entry.until.Before(until) {
+		entry.until = until
+		entry.runs = runs
+		c.entries[jobId] = entry
+	}
+}
+
+// put caches the runs of a job. Once the cache is full, expired entries are evicted, and the
+// least recently fetched one when none has expired.
+func (c *runHistoryCache) put(jobId int64, until time.Time, count int, runs []jobs.BaseRun, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[jobId]; !ok && len(c.entries) >= runHistoryMaxJobs {
+		maps.DeleteFunc(c.entries, func(_ int64, entry runHistoryEntry) bool {
+			return now.Sub(entry.fetchedAt) > runHistoryTTL
+		})
+	}
+
+	if _, ok := c.entries[jobId]; !ok && len(c.entries) >= runHistoryMaxJobs {
+		oldest := slices.MinFunc(slices.Collect(maps.Keys(c.entries)), func(i, j int64) int {
+			return c.entries[i].fetchedAt.Compare(c.entries[j].fetchedAt)
+		})
+
+		delete(c.entries, oldest)
+	}
+
+	c.entries[jobId] = runHistoryEntry{until: until, fetchedAt: now, count: count, runs: runs}
+}
+
+// runDuration returns the duration of a finished run in milliseconds. Single task runs
+// report setup, execution and cleanup durations instead of a run duration.
+func runDuration(run jobs.BaseRun) int64 {
+	if run.RunDuration > 0 {
+		return run.RunDuration
+	}
+
+	if d := run.SetupDuration + run.ExecutionDuration + run.CleanupDuration; d > 0 {
+		return d
+	}
+
+	return run.EndTime - run.StartTime
+}
+
+// fetchSuccessfulRuns returns up to count successful runs of a job that started before
+// until, and at or after since when set, most recent first. At most five times count runs
+// are scanned.
+func fetchSuccessfulRuns(ctx context.Context, client DatabricksJobsService, jobId int64, since time.Time, until time.Time, count int) ([]jobs.BaseRun, error) {
+	request := jobs.ListRunsRequest{
+		JobId:         jobId,
+		CompletedOnly: true,
+		StartTimeTo:   until.UnixMilli() - 1,
+		Limit:         25,
+	}
+
+	if !since.IsZero() {
+		request.StartTimeFrom = since.UnixMilli()
+	}
+
+	it := client.ListRuns(ctx, request)
+
+	runs := make([]jobs.BaseRun, 0, count)
+	for scanned := 0; it.HasNext(ctx) && scanned < 5*count && len(runs) < count; scanned++ {
+		run, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if runSucceeded(run) {
+			runs = append(runs, run)
+		}
+	}
+
+	return runs, nil
+}
+
+// fetchRunHistory returns the successful runs of every job in runs before the given point in
+// time (the start of the range, or of the earliest run of the job).
+func (c *runHistoryCache) fetchRunHistory(ctx context.Context, client DatabricksJobsService, runs []jobs.BaseRun, from time.Time, count int) (map[int64][]jobs.BaseRun, error) {
+	until := map[int64]time.Time{}
+	for _, run := range runs {
+		start := time.UnixMilli(run.StartTime)
+		if !from.IsZero() {
+			start = from
+		}
+
+		if t, ok := until[run.JobId]; !ok || start.Before(t) {
+			until[run.JobId] = start
+		}
+	}
+
+	now := time.Now()
+	history := map[int64][]jobs.BaseRun{}
+	for jobId, t := range until {
+		if entry, ok := c.get(jobId, t, count, now); ok {
+			runs := entry.runs
+			if entry.until.Before(t) {
+				// the runs since the cached point in time are fetched, so none are missed
+				newer, err := fetchSuccessfulRuns(ctx, client, jobId, entry.until, t, count)
+				if err != nil {
+					return nil, err
+				}
+
+				runs = slices.Concat(newer, runs)
+				runs = runs[:min(len(runs), count)]
+				c.extend(jobId, t, runs)
+			}
+
+			history[jobId] = runs
+			continue
+		}
+
+		runs, err := fetchSuccessfulRuns(ctx, client, jobId, time.Time{}, t, count)
+		if err != nil {
+			return nil, err
+		}
+
+		c.put(jobId, t, count, runs, now)
+		history[jobId] = runs
+	}
+
+	return history, nil
+}
+
+// computeRunAnomalies compares the duration of every finished run to the median and median
+// absolute deviation (MAD) of the count successful runs of the same job before it. The score
+// is the robust z-score, null when all baseline durations are equal.
+func computeRunAnomalies(runs []jobs.BaseRun, history map[int64][]jobs.BaseRun, count int) map[int64]runAnomaly {
+	byJob := map[int64][]jobs.BaseRun{}
+	for _, run := range runs {
+		byJob[run.JobId] = append(byJob[run.JobId], run)
+	}
+
+	anomalies := map[int64]runAnomaly{}
+	for jobId, jobRuns := range byJob {
+		successful := slices.Clone(history[jobId])
+		for _, run := range jobRuns {
+			if runSucceeded(run) {
+				successful = append(successful, run)
+			}
+		}
+
+		slices.SortFunc(successful, func(i, j jobs.BaseRun) int {
+			return cmp.Compare(i.StartTime, j.StartTime)
+		})
+
+		for _, run := range jobRuns {
+			if runActive(run) {
+				continue
+			}
+
+			// successful runs that started before this one
+			n := slices.IndexFunc(successful, func(r jobs.BaseRun) bool {
+				return r.StartTime >= run.StartTime
+			})
+			if n < 0 {
+				n = len(successful)
+			}
+
+			baselineRuns := successful[max(0, n-count):n]
+			if len(baselineRuns) < minBaselineRuns {
+				continue
+			}
+
+			durations := make([]float64, len(baselineRuns))
+			for i, r := range baselineRuns {
+				durations[i] = float64(runDuration(r))
+			}
+
+			baseline := median(durations)
+			deviations := make([]float64, len(durations))
+			for i, d := range durations {
+				deviations[i] = math.Abs(d - baseline)
+			}
+
+			duration := float64(runDuration(run))
+			anomaly := runAnomaly{Baseline: baseline}
+			if baseline > 0 {
+				anomaly.Slower = (duration - baseline) / baseline * 100
+			}
+
+			if mad := median(deviations); mad > 0 {
+				score := (duration - baseline) / (madScale * mad)
+				anomaly.Score = &score
+			}
+
+			anomalies[run.RunId] = anomaly
+		}
+	}
+
+	return anomalies
+}
+
+// addJobRunAnomalyFields appends the baseline and anomaly columns to a job run frame.
+func addJobRunAnomalyFields(frame *data.Frame, anomalies map[int64]runAnomaly) error {
+	runIds, err := frameRunIds(frame)
+	if err != nil {
+		return err
+	}
+
+	baseline := make([]*float64, len(runIds))
+	score := make([]*float64, len(runIds))
+	slower := make([]*float64, len(runIds))
+
+	for i, runId := range runIds {
+		if anomaly, ok := anomalies[runId]; ok {
+			baseline[i] = &anomaly.Baseline
+			score[i] = anomaly.Score
+			slower[i] = &anomaly.Slower
+		}
+	}
+
+	frame.Fields = slices.Concat(frame.Fields, []*data.Field{
+		data.NewField("Baseline Duration (milliseconds)", nil, baseline),
+		data.NewField("Anomaly Score", nil, score),
+		data.NewField("Slower Than Baseline (%)", nil, slower),
+	})
+
+	return nil
+}
+
+// buildJobRunAnomalyFrames returns one numeric frame per job with the anomaly score of its
+// latest scored run, so each job becomes a separate alert instance. The run is only a field,
+// not a label, so the alert instance of a job stays the same from one run to the next.
+func buildJobRunAnomalyFrames(runs []jobs.BaseRun, anomalies map[int64]runAnomaly) []*data.Frame {
+	latest := map[int64]jobs.BaseRun{}
+	for _, run := range runs {
+		anomaly, ok := anomalies[run.RunId]
+		if !ok || anomaly.Score == nil {
+			continue
+		}
+
+		if l, ok := latest[run.JobId]; !ok || run.StartTime > l.StartTime {
+			latest[run.JobId] = run
+		}
+	}
+
+	frames := []*data.Frame{}
+	for _, jobId := range slices.Sorted(maps.Keys(latest)) {
+		run := latest[jobId]
+		labels := data.Labels{
+			"job_id":   strconv.FormatInt(run.JobId, 10),
+			"job_name": run.RunName,
+		}
+
+		// the run id is a string, as a numeric frame may only have a single number field
+		frame := data.NewFrame(run.RunName,
+			data.NewField("Anomaly Score", labels, []float64{*anomalies[run.RunId].Score}),
+			data.NewField("Run ID", nil, []string{strconv.FormatInt(run.RunId, 10)}),
+		)
+		frame.SetMeta(&data.FrameMeta{Type: data.FrameTypeNumericMulti})
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/listing"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+)
+
+// fakeJobsService returns the given runs and counts the calls.
+type fakeJobsService struct {
+	runs  []jobs.BaseRun
+	calls int
+}
+
+// ListRuns returns the runs within the start time range of the request, most recent first.
+func (f *fakeJobsService) ListRuns(_ context.Context, request jobs.ListRunsRequest) listing.Iterator[jobs.BaseRun] {
+	f.calls++
+	runs := slices.DeleteFunc(slices.Clone(f.runs), func(run jobs.BaseRun) bool {
+		return (request.StartTimeFrom != 0 && run.StartTime < request.StartTimeFrom) ||
+			(request.StartTimeTo != 0 && run.StartTime > request.StartTimeTo)
+	})
+	slices.SortStableFunc(runs, func(i, j jobs.BaseRun) int {
+		return cmp.Compare(j.StartTime, i.StartTime)
+	})
+
+	it := listing.SliceIterator[jobs.BaseRun](runs)
+	return &it
+}
+
+func TestComputeRunAnomalies(t *testing.T) {
+	t.Parallel()
+
+	success := &jobs.RunState{ResultState: jobs.RunResultStateSuccess}
+	run := func(runId int64, start int64, duration int64, state *jobs.RunState) jobs.BaseRun {
+		return jobs.BaseRun{JobId: 1, RunId: runId, StartTime: start, EndTime: start + duration, RunDuration: duration, State: state}
+	}
+
+	history := map[int64][]jobs.BaseRun{
+		1: {run(1, 100, 90, success), run(2, 200, 100, success), run(3, 300, 110, success)},
+	}
+
+	runs := []jobs.BaseRun{
+		run(4, 400, 200, success),
+		run(5, 500, 100, &jobs.RunState{ResultState: jobs.RunResultStateFailed}),
+		{JobId: 1, RunId: 6, StartTime: 600},
+	}
+
+	anomalies := computeRunAnomalies(runs, history, 3)
+
+	slow, ok := anomalies[4]
+	if !ok || slow.Baseline != 100 || slow.Slower != 100 {
+		t.Fatalf("unexpected anomaly of the slow run: %+v", slow)
+	}
+
+	// MAD of 90, 100, 110 is 10
+	if *slow.Score < 6.7 || *slow.Score > 6.8 {
+		t.Errorf("unexpected score: %v", *slow.Score)
+	}
+
+	// the baseline of the failed run rolls over to the last three successful runs before it
+	if anomalies[5].Baseline != 110 {
+		t.Errorf("expected rolling baseline of 110, got %v", anomalies[5].Baseline)
+	}
+
+	if _, ok := anomalies[6]; ok {
+		t.Error("expected no anomaly for active runs")
+	}
+
+	frames := buildJobRunAnomalyFrames(runs, anomalies)
+	if len(frames) != 1 || frames[0].Fields[1].At(0).(string) != "5" {
+		t.Errorf("expected frame for the latest scored run")
+	}
+
+	if labels := frames[0].Fields[0].Labels; len(labels) != 2 || labels["job_id"] != "1" {
+		t.Errorf("expected only job labels, got %v", labels)
+	}
+}
+
+func TestRunHistoryCache(t *testing.T) {
+	t.Parallel()
+
+	from := time.Now().Add(-time.Hour)
+	success := &jobs.RunState{ResultState: jobs.RunResultStateSuccess}
+	client := &fakeJobsService{runs: []jobs.BaseRun{
+		{JobId: 1, RunId: 1, StartTime: from.Add(-time.Hour).UnixMilli(), State: success},
+		{JobId: 1, RunId: 2, StartTime: from.Add(-time.Hour).UnixMilli(), State: &jobs.RunState{ResultState: jobs.RunResultStateFailed}},
+		{JobId: 1, RunId: 4, StartTime: from.Add(30 * time.Second).UnixMilli(), State: success},
+	}}
+
+	cache := newRunHistoryCache()
+	runs := []jobs.BaseRun{{JobId: 1, RunId: 3}}
+
+	history, err := cache.fetchRunHistory(context.Background(), client, runs, from, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history[1]) != 1 {
+		t.Errorf("expected only successful runs, got %d", len(history[1]))
+	}
+
+	if _, err := cache.fetchRunHistory(context.Background(), client, runs, from, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.calls != 1 {
+		t.Errorf("expected cached history for the same range, got %d calls", client.calls)
+	}
+
+	history, err = cache.fetchRunHistory(context.Background(), client, runs, from.Add(time.Minute), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.calls != 2 || len(history[1]) != 2 || history[1][0].RunId != 4 {
+		t.Errorf("expected the runs since the cached range to be merged, got %d calls and %v", client.calls, history[1])
+	}
+
+	if _, err := cache.fetchRunHistory(context.Background(), client, runs, from.Add(-time.Minute), 20); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.calls != 3 {
+		t.Errorf("expected refetch when the range moved back, got %d calls", client.calls)
+	}
+}
+
+func TestRunHistoryCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := newRunHistoryCache()
+	for jobId := range int64(runHistoryMaxJobs) {
+		cache.put(jobId, now, 20, nil, now.Add(time.Duration(jobId)*time.Millisecond))
+	}
+
+	cache.put(runHistoryMaxJobs, now, 20, nil, now.Add(time.Second))
+	if len(cache.entries) != runHistoryMaxJobs {
+		t.Fatalf("expected a full cache, got %d entries", len(cache.entries))
+	}
+
+	if _, ok := cache.entries[0]; ok {
+		t.Error("expected the least recently fetched job to be evicted")
+	}
+
+	cache.put(runHistoryMaxJobs+1, now, 20, nil, now.Add(runHistoryTTL+time.Hour))
+	if len(cache.entries) != 1 {
+		t.Errorf("expected expired entries to be evicted, got %d entries", len(cache.entries))
+	}
+}
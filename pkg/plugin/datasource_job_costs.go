@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type jobRunCost struct {
+	DBUs     *float64
+	ListCost *float64
+}
+
+// buildJobRunCostStatement looks up the usage of all given runs in a single statement. Run
+// ids are passed as one comma separated parameter to keep the statement text fixed. Run ids
+// are only unique within a workspace, so the usage is limited to the workspace of the runs
+// when it is set, i.e. in account mode.
+func buildJobRunCostStatement(runs []jobs.BaseRun, workspaceId string) (string, []sql.StatementParameterListItem) {
+	ids := []string{}
+	earliest := time.Now().UnixMilli()
+	for _, run := range runs {
+		ids = append(ids, strconv.FormatInt(run.RunId, 10))
+		if run.StartTime != 0 {
+			earliest = min(earliest, run.StartTime)
+		}
+	}
+
+	workspaceFilter := ""
+	if workspaceId != "" {
+		workspaceFilter = "\n  AND u.workspace_id = :workspace_id"
+	}
+
+	statement := fmt.Sprintf(`SELECT u.usage_metadata.job_run_id AS run_id,
+  SUM(u.usage_quantity) AS dbus,
+  SUM(u.usage_quantity * p.pricing.default) AS list_cost
+%s
+WHERE u.usage_end_time >= :from
+  AND array_contains(split(:run_ids, ','), u.usage_metadata.job_run_id)%s
+GROUP BY 1`, usageWithPricesSource, workspaceFilter)
+
+	parameters := []sql.StatementParameterListItem{
+		timestampParameter("from", time.UnixMilli(earliest)),
+		stringParameter("run_ids", strings.Join(ids, ",")),
+	}
+
+	if workspaceId != "" {
+		parameters = append(parameters, stringParameter("workspace_id", workspaceId))
+	}
+
+	return statement, parameters
+}
+
+func parseJobRunCosts(result *statementResult) (map[int64]jobRunCost, error) {
+	costs := map[int64]jobRunCost{}
+	for _, row := range result.Rows {
+		runId, err := strconv.ParseInt(result.value(row, "run_id"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run id: %v", err)
+		}
+
+		dbus, err := parseStatementFloat(result.value(row, "dbus"))
+		if err != nil {
+			return nil, err
+		}
+
+		cost, err := parseStatementFloat(result.value(row, "list_cost"))
+		if err != nil {
+			return nil, err
+		}
+
+		costs[runId] = jobRunCost{DBUs: dbus, ListCost: cost}
+	}
+
+	return costs, nil
+}
+
+func fetchJobRunCosts(ctx context.Context, w *databricks.WorkspaceClient, warehouseId string, runs []jobs.BaseRun, workspaceId string) (map[int64]jobRunCost, error) {
+	if len(runs) == 0 {
+		return map[int64]jobRunCost{}, nil
+	}
+
+	statement, parameters := buildJobRunCostStatement(runs, workspaceId)
+	result, err := executeStatement(ctx, w, warehouseId, statement, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJobRunCosts(result)
+}
+
+// addJobRunCostFields appends the estimated DBU and cost columns to a job run frame. Runs
+// without usage records, or all runs when costs is nil, get null values.
+func addJobRunCostFields(frame *data.Frame, costs map[int64]jobRunCost) error {
+	runIds, err := frameRunIds(frame)
+	if err != nil {
+		return err
+	}
+
+	dbus := make([]*float64, len(runIds))
+	listCost := make([]*float64, len(runIds))
+
+	for i, runId := range runIds {
+		if cost, ok := costs[runId]; ok {
+			dbus[i] = cost.DBUs
+			listCost[i] = cost.ListCost
+		}
+	}
+
+	frame.Fields = slices.Concat(frame.Fields, []*data.Field{
+		data.NewField("Estimated DBUs", nil, dbus),
+		data.NewField("Estimated List Cost", nil, listCost),
+	})
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// logicalRun is a run together with its retry attempts.
+type logicalRun struct {
+	JobID    int64
+	JobName  string
+	RunID    int64
+	Start    time.Time
+	Attempts []jobs.BaseRun
+}
+
+type jobFlakiness struct {
+	JobID                   int64
+	JobName                 string
+	LogicalRuns             int64
+	RetriedRuns             int64
+	FinalFailures           int64
+	RetryRate               float64
+	FirstAttemptFailureRate *float64
+	MeanAttempts            float64
+}
+
+// groupLogicalRuns groups retry attempts with the run they retry, using the original attempt
+// run id. Attempts are sorted by attempt number.
+func groupLogicalRuns(runs []jobs.BaseRun) []logicalRun {
+	byId := map[int64]*logicalRun{}
+	for _, run := range runs {
+		id := run.RunId
+		if run.OriginalAttemptRunId != 0 {
+			id = run.OriginalAttemptRunId
+		}
+
+		logical, ok := byId[id]
+		if !ok {
+			logical = &logicalRun{JobID: run.JobId, RunID: id}
+			byId[id] = logical
+		}
+
+		logical.Attempts = append(logical.Attempts, run)
+	}
+
+	logicalRuns := []logicalRun{}
+	for _, logical := range byId {
+		slices.SortFunc(logical.Attempts, func(i, j jobs.BaseRun) int {
+			return cmp.Compare(i.AttemptNumber, j.AttemptNumber)
+		})
+
+		first := logical.Attempts[0]
+		logical.JobName = first.RunName
+		logical.Start = time.UnixMilli(first.StartTime)
+		logicalRuns = append(logicalRuns, *logical)
+	}
+
+	slices.SortFunc(logicalRuns, func(i, j logicalRun) int {
+		return i.Start.Compare(j.Start)
+	})
+
+	return logicalRuns
+}
+
+// taskRetries returns the number of task retries within a run. Multi-task jobs retry a failed
+// task within the same run, as a new attempt of the task, so these only show up in the tasks
+// of a run listed with expanded tasks.
+func taskRetries(run jobs.BaseRun) int {
+	attempts := map[string]int{}
+	for _, task := range run.Tasks {
+		attempts[task.TaskKey] = max(attempts[task.TaskKey], task.AttemptNumber)
+	}
+
+	retries := 0
+	for _, attempt := range attempts {
+		retries += attempt
+	}
+
+	return retries
+}
+
+// attempts returns the number of attempts made, including earlier attempts that fell
+// outside of the fetched runs and the task retries within each attempt.
+func (r logicalRun) attempts() int {
+	last := r.Attempts[len(r.Attempts)-1]
+	attempts := max(len(r.Attempts), last.AttemptNumber+1)
+	for _, attempt := range r.Attempts {
+		attempts += taskRetries(attempt)
+	}
+
+	return attempts
+}
+
+// firstAttemptFailed reports whether the first attempt finished without success, or false
+// while it is still running.
+func (r logicalRun) firstAttemptFailed() (failed bool, finished bool) {
+	first := r.Attempts[0]
+	if first.AttemptNumber > 0 {
+		// the first attempt wasn't fetched, but a retry implies it failed
+		return true, true
+	}
+
+	if taskRetries(first) > 0 {
+		// a task of the first attempt failed, even if the run recovered
+		return true, true
+	}
+
+	if runActive(first) {
+		return false, false
+	}
+
+	return !runSucceeded(first), true
+}
+
+// computeJobFlakiness aggregates logical runs per job.
+func computeJobFlakiness(logicalRuns []logicalRun) []jobFlakiness {
+	type totals struct {
+		flakiness     jobFlakiness
+		attempts      int64
+		firstFinished int64
+		firstFailed   int64
+	}
+
+	byJob := map[int64]*totals{}
+	for _, run := range logicalRuns {
+		t, ok := byJob[run.JobID]
+		if !ok {
+			t = &totals{flakiness: jobFlakiness{JobID: run.JobID}}
+			byJob[run.JobID] = t
+		}
+
+		// the most recent run name is used as the job name
+		t.flakiness.JobName = run.JobName
+		t.flakiness.LogicalRuns++
+		t.attempts += int64(run.attempts())
+
+		if run.attempts() > 1 {
+			t.flakiness.RetriedRuns++
+		}
+
+		if failed, finished := run.firstAttemptFailed(); finished {
+			t.firstFinished++
+			if failed {
+				t.firstFailed++
+			}
+		}
+
+		last := run.Attempts[len(run.Attempts)-1]
+		if !runActive(last) && !runSucceeded(last) {
+			t.flakiness.FinalFailures++
+		}
+	}
+
+	results := []jobFlakiness{}
+	for _, jobId := range slices.Sorted(maps.Keys(byJob)) {
+		t := byJob[jobId]
+		f := t.flakiness
+		f.RetryRate = float64(f.RetriedRuns) / float64(f.LogicalRuns)
+		f.MeanAttempts = float64(t.attempts) / float64(f.LogicalRuns)
+		if t.firstFinished > 0 {
+			rate := float64(t.firstFailed) / float64(t.firstFinished)
+			f.FirstAttemptFailureRate = &rate
+		}
+
+		results = append(results, f)
+	}
+
+	// rank the flakiest jobs first
+	slices.SortStableFunc(results, func(i, j jobFlakiness) int {
+		var a, b float64
+		if i.FirstAttemptFailureRate != nil {
+			a = *i.FirstAttemptFailureRate
+		}
+		if j.FirstAttemptFailureRate != nil {
+			b = *j.FirstAttemptFailureRate
+		}
+
+		return cmp.Or(cmp.Compare(j.RetryRate, i.RetryRate), cmp.Compare(b, a))
+	})
+
+	return results
+}
+
+func buildJobFlakinessFrame(results []jobFlakiness, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Job Flakiness",
+		data.NewField("Rank", nil, []int64{}),
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		data.NewField("Job Name", nil, []string{}),
+		data.NewField("Logical Runs", nil, []int64{}),
+		data.NewField("Retried Runs", nil, []int64{}),
+		data.NewField("Retry Rate", nil, []float64{}),
+		data.NewField("First Attempt Failure Rate", nil, []*float64{}),
+		data.NewField("Mean Attempts", nil, []float64{}),
+		data.NewField("Final Failures", nil, []int64{}),
+	)
+
+	for i, result := range results {
+		frame.AppendRow(
+			int64(i+1),
+			result.JobID,
+			result.JobName,
+			result.LogicalRuns,
+			result.RetriedRuns,
+			result.RetryRate,
+			result.FirstAttemptFailureRate,
+			result.MeanAttempts,
+			result.FinalFailures,
+		)
+	}
+
+	return frame
+}
+
+// buildJobFlakinessFrames returns one frame per job with its flakiness per time bucket, by
+// the start of the logical runs.
+func buildJobFlakinessFrames(logicalRuns []logicalRun, times []time.Time) []*data.Frame {
+	byJob := map[int64][][]logicalRun{}
+	names := map[int64]string{}
+	for _, run := range logicalRuns {
+		i := bucketIndex(times, run.Start)
+		if i < 0 {
+			continue
+		}
+
+		if _, ok := byJob[run.JobID]; !ok {
+			byJob[run.JobID] = make([][]logicalRun, len(times))
+		}
+
+		byJob[run.JobID][i] = append(byJob[run.JobID][i], run)
+		names[run.JobID] = run.JobName
+	}
+
+	frames := []*data.Frame{}
+	for _, jobId := range slices.Sorted(maps.Keys(byJob)) {
+		labels := data.Labels{"job_id": strconv.FormatInt(jobId, 10), "job_name": names[jobId]}
+		retryRate := make([]*float64, len(times))
+		firstFailureRate := make([]*float64, len(times))
+		meanAttempts := make([]*float64, len(times))
+
+		for i, bucket := range byJob[jobId] {
+			if len(bucket) == 0 {
+				continue
+			}
+
+			f := computeJobFlakiness(bucket)[0]
+			retryRate[i] = &f.RetryRate
+			firstFailureRate[i] = f.FirstAttemptFailureRate
+			meanAttempts[i] = &f.MeanAttempts
+		}
+
+		frame := data.NewFrame(names[jobId],
+			data.NewField("Time", nil, times),
+			data.NewField("Retry Rate", labels, retryRate),
+			data.NewField("First Attempt Failure Rate", labels, firstFailureRate),
+			data.NewField("Mean Attempts", labels, meanAttempts),
+		)
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryJobFlakiness(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel, params jobRunParams) backend.DataResponse {
+	if params.Format != jobRunFormatTable && params.Format != jobRunFormatTimeSeries {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("format %s is not supported in flakiness mode", params.Format))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request, err := buildListRunsRequest(params, query)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build list runs request: %v", err))
+	}
+
+	// the tasks are needed to count the task retries of multi-task jobs
+	request.ExpandTasks = true
+
+	jobsService := &workspaceClientWrapper{client: w}
+	runs, err := fetchJobRuns(ctx, jobsService, request, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch job runs: %v", err))
+	}
+
+	logicalRuns := groupLogicalRuns(runs)
+	if params.Format == jobRunFormatTimeSeries {
+		return backend.DataResponse{
+			Frames: withExecutedQuery(buildJobFlakinessFrames(logicalRuns, sampleTimes(query)), describeListRuns(request)),
+		}
+	}
+
+	frame := buildJobFlakinessFrame(computeJobFlakiness(logicalRuns), w.Config.Host)
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeListRuns(request)),
+	}
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+)
+
+func TestJobFlakiness(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	success := &jobs.RunState{ResultState: jobs.RunResultStateSuccess}
+	failed := &jobs.RunState{ResultState: jobs.RunResultStateFailed}
+	attempt := func(jobId int64, runId int64, original int64, number int, start time.Duration, state *jobs.RunState) jobs.BaseRun {
+		return jobs.BaseRun{
+			JobId:                jobId,
+			RunId:                runId,
+			RunName:              "job",
+			OriginalAttemptRunId: original,
+			AttemptNumber:        number,
+			StartTime:            from.Add(start).UnixMilli(),
+			EndTime:              from.Add(start + time.Minute).UnixMilli(),
+			State:                state,
+		}
+	}
+
+	runs := []jobs.BaseRun{
+		// job 1: one clean run, one run that succeeded on the third attempt
+		attempt(1, 1, 0, 0, 0, success),
+		attempt(1, 2, 0, 0, time.Hour, failed),
+		attempt(1, 3, 2, 1, time.Hour+5*time.Minute, failed),
+		attempt(1, 4, 2, 2, time.Hour+10*time.Minute, success),
+		// job 2: a run whose first attempt is outside of the fetched runs, and a clean run
+		attempt(2, 6, 5, 1, 2*time.Hour, failed),
+		attempt(2, 7, 0, 0, 3*time.Hour, success),
+	}
+
+	logicalRuns := groupLogicalRuns(runs)
+	if len(logicalRuns) != 4 {
+		t.Fatalf("expected 4 logical runs, got %d", len(logicalRuns))
+	}
+
+	results := computeJobFlakiness(logicalRuns)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(results))
+	}
+
+	job1 := results[0]
+	if job1.JobID != 1 || job1.RetryRate != 0.5 || *job1.FirstAttemptFailureRate != 0.5 || job1.MeanAttempts != 2 || job1.FinalFailures != 0 {
+		t.Errorf("unexpected flakiness of job 1: %+v", job1)
+	}
+
+	job2 := results[1]
+	if job2.RetryRate != 0.5 || job2.MeanAttempts != 1.5 || job2.FinalFailures != 1 {
+		t.Errorf("unexpected flakiness of job 2: %+v", job2)
+	}
+
+	t.Run("should count task retries of multi-task jobs", func(t *testing.T) {
+		run := attempt(3, 8, 0, 0, 4*time.Hour, success)
+		run.Tasks = []jobs.RunTask{
+			{TaskKey: "ingest", AttemptNumber: 0},
+			{TaskKey: "transform", AttemptNumber: 0},
+			{TaskKey: "transform", AttemptNumber: 1},
+			{TaskKey: "transform", AttemptNumber: 2},
+		}
+
+		results := computeJobFlakiness(groupLogicalRuns([]jobs.BaseRun{run, attempt(3, 9, 0, 0, 5*time.Hour, success)}))
+		if len(results) != 1 {
+			t.Fatalf("expected 1 job, got %d", len(results))
+		}
+
+		job3 := results[0]
+		if job3.RetryRate != 0.5 || *job3.FirstAttemptFailureRate != 0.5 || job3.MeanAttempts != 2 || job3.FinalFailures != 0 {
+			t.Errorf("unexpected flakiness of job 3: %+v", job3)
+		}
+	})
+
+	t.Run("should bucket by logical run start", func(t *testing.T) {
+		times := []time.Time{from, from.Add(2 * time.Hour)}
+		frames := buildJobFlakinessFrames(logicalRuns, times)
+		if len(frames) != 2 {
+			t.Fatalf("expected 2 frames, got %d", len(frames))
+		}
+
+		retryRate, _ := frames[0].FieldByName("Retry Rate")
+		if *retryRate.At(0).(*float64) != 0.5 || retryRate.At(1).(*float64) != nil {
+			t.Errorf("unexpected retry rates of job 1: %v, %v", retryRate.At(0), retryRate.At(1))
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// runOutcome is the result of a finished logical run, i.e. of its last attempt.
+type runOutcome struct {
+	Start  time.Time
+	End    time.Time
+	Failed bool
+}
+
+// failureEpisode is a sequence of failed runs of a job, from the end of the first failed run
+// until the end of the next successful run.
+type failureEpisode struct {
+	Start     time.Time
+	Recovered *time.Time
+	Failures  int64
+}
+
+type jobReliability struct {
+	JobID         int64
+	JobName       string
+	Runs          int64
+	Failures      int64
+	Episodes      int64
+	MTTR          *float64
+	MTBF          *float64
+	FailureStreak int64
+	LastSuccess   *time.Time
+}
+
+// jobRunOutcomes returns the outcomes of the finished logical runs of each job, sorted by
+// start time.
+func jobRunOutcomes(logicalRuns []logicalRun) (map[int64][]runOutcome, map[int64]string) {
+	outcomes := map[int64][]runOutcome{}
+	names := map[int64]string{}
+	for _, run := range logicalRuns {
+		last := run.Attempts[len(run.Attempts)-1]
+		if runActive(last) {
+			continue
+		}
+
+		outcomes[run.JobID] = append(outcomes[run.JobID], runOutcome{
+			Start:  run.Start,
+			End:    time.UnixMilli(last.EndTime),
+			Failed: !runSucceeded(last),
+		})
+		names[run.JobID] = run.JobName
+	}
+
+	return outcomes, names
+}
+
+func failureEpisodes(outcomes []runOutcome) []failureEpisode {
+	episodes := []failureEpisode{}
+	var current *failureEpisode
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.Failed && current == nil:
+			current = &failureEpisode{Start: outcome.End, Failures: 1}
+		case outcome.Failed:
+			current.Failures++
+		case current != nil:
+			recovered := outcome.End
+			current.Recovered = &recovered
+			episodes = append(episodes, *current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		episodes = append(episodes, *current)
+	}
+
+	return episodes
+}
+
+func meanSeconds(durations []time.Duration) *float64 {
+	if len(durations) == 0 {
+		return nil
+	}
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+
+	mean := total.Seconds() / float64(len(durations))
+	return &mean
+}
+
+// recoveryTimes returns the time to recovery of every recovered episode.
+func recoveryTimes(episodes []failureEpisode) []time.Duration {
+	durations := []time.Duration{}
+	for _, episode := range episodes {
+		if episode.Recovered != nil {
+			durations = append(durations, episode.Recovered.Sub(episode.Start))
+		}
+	}
+
+	return durations
+}
+
+// timesBetweenFailures returns the time between the start of consecutive episodes.
+func timesBetweenFailures(episodes []failureEpisode) []time.Duration {
+	durations := []time.Duration{}
+	for i := 1; i < len(episodes); i++ {
+		durations = append(durations, episodes[i].Start.Sub(episodes[i-1].Start))
+	}
+
+	return durations
+}
+
+// computeJobReliability summarises the outcomes of a job: the mean time to recovery (MTTR),
+// the mean time between failures (MTBF), the number of failed runs since the last success
+// and the last success time.
+func computeJobReliability(outcomes []runOutcome) jobReliability {
+	episodes := failureEpisodes(outcomes)
+	result := jobReliability{
+		Runs:     int64(len(outcomes)),
+		Episodes: int64(len(episodes)),
+		MTTR:     meanSeconds(recoveryTimes(episodes)),
+		MTBF:     meanSeconds(timesBetweenFailures(episodes)),
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Failed {
+			result.Failures++
+			result.FailureStreak++
+			continue
+		}
+
+		end := outcome.End
+		result.LastSuccess = &end
+		result.FailureStreak = 0
+	}
+
+	return result
+}
+
+func buildJobReliabilityFrame(outcomes map[int64][]runOutcome, names map[int64]string, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Job Reliability",
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		data.NewField("Job Name", nil, []string{}),
+		data.NewField("Runs", nil, []int64{}),
+		data.NewField("Failures", nil, []int64{}),
+		data.NewField("Failure Episodes", nil, []int64{}),
+		data.NewField("MTTR (seconds)", nil, []*float64{}),
+		data.NewField("MTBF (seconds)", nil, []*float64{}),
+		data.NewField("Current Failure Streak", nil, []int64{}),
+		data.NewField("Last Success", nil, []*time.Time{}),
+	)
+
+	for _, jobId := range slices.Sorted(maps.Keys(outcomes)) {
+		result := computeJobReliability(outcomes[jobId])
+		frame.AppendRow(
+			jobId,
+			names[jobId],
+			result.Runs,
+			result.Failures,
+			result.Episodes,
+			result.MTTR,
+			result.MTBF,
+			result.FailureStreak,
+			result.LastSuccess,
+		)
+	}
+
+	return frame
+}
+
+// buildJobReliabilityFrames returns one frame per job with the failed runs, the MTTR of the
+// episodes recovered and the MTBF of the episodes started in each time bucket.
+func buildJobReliabilityFrames(outcomes map[int64][]runOutcome, names map[int64]string, times []time.Time) []*data.Frame {
+	frames := []*data.Frame{}
+	for _, jobId := range slices.Sorted(maps.Keys(outcomes)) {
+		failures := make([]int64, len(times))
+		recoveries := make([][]time.Duration, len(times))
+		between := make([][]time.Duration, len(times))
+
+		for _, outcome := range outcomes[jobId] {
+			if i := bucketIndex(times, outcome.Start); i >= 0 && outcome.Failed {
+				failures[i]++
+			}
+		}
+
+		episodes := failureEpisodes(outcomes[jobId])
+		for n, episode := range episodes {
+			if episode.Recovered != nil {
+				if i := bucketIndex(times, *episode.Recovered); i >= 0 {
+					recoveries[i] = append(recoveries[i], episode.Recovered.Sub(episode.Start))
+				}
+			}
+
+			if i := bucketIndex(times, episode.Start); i >= 0 && n > 0 {
+				between[i] = append(between[i], episode.Start.Sub(episodes[n-1].Start))
+			}
+		}
+
+		mttr := make([]*float64, len(times))
+		mtbf := make([]*float64, len(times))
+		for i := range times {
+			mttr[i] = meanSeconds(recoveries[i])
+			mtbf[i] = meanSeconds(between[i])
+		}
+
+		labels := data.Labels{"job_id": strconv.FormatInt(jobId, 10), "job_name": names[jobId]}
+		frame := data.NewFrame(names[jobId],
+			data.NewField("Time", nil, times),
+			data.NewField("Failures", labels, failures),
+			data.NewField("MTTR (seconds)", labels, mttr),
+			data.NewField("MTBF (seconds)", labels, mtbf),
+		)
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryJobReliability(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel, params jobRunParams) backend.DataResponse {
+	if params.Format != jobRunFormatTable && params.Format != jobRunFormatTimeSeries {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("format %s is not supported in reliability mode", params.Format))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request, err := buildListRunsRequest(params, query)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build list runs request: %v", err))
+	}
+
+	executed := []string{}
+	jobIds := []int64{request.JobId}
+	if params.JobID == "" {
+		listJobsRequest := jobs.ListJobsRequest{Limit: 100}
+		allJobs, err := fetchWithLimit(ctx, w.Jobs.List(ctx, listJobsRequest), qm.Limit)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list jobs: %v", err))
+		}
+
+		jobIds = []int64{}
+		for _, job := range allJobs {
+			jobIds = append(jobIds, job.JobId)
+		}
+
+		executed = append(executed, describeRequest("GET", "/api/2.2/jobs/list", listJobsRequest))
+	}
+
+	// the runs are listed per job, so the runs of a busy job don't crowd out those of the others
+	for _, jobId := range jobIds {
+		request.JobId = jobId
+		executed = append(executed, describeListRuns(request))
+	}
+
+	jobsService := &workspaceClientWrapper{client: w}
+	perJob, err := fetchRunsPerJob(ctx, jobsService, request, jobIds, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch job runs: %v", err))
+	}
+
+	notices := []data.Notice{}
+	if params.JobID == "" && len(jobIds) >= qm.Limit {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the first %d jobs are evaluated, increase Max Results to see the rest", qm.Limit),
+		})
+	}
+
+	for i, runs := range perJob {
+		if len(runs) >= qm.Limit {
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("run limit of %d reached for job %d, its earlier runs in the time range are not evaluated", qm.Limit, jobIds[i]),
+			})
+		}
+	}
+
+	// retries are grouped, so a failure recovered by a retry counts as a success
+	outcomes, names := jobRunOutcomes(groupLogicalRuns(slices.Concat(perJob...)))
+
+	frames := []*data.Frame{buildJobReliabilityFrame(outcomes, names, w.Config.Host)}
+	if params.Format == jobRunFormatTimeSeries {
+		frames = buildJobReliabilityFrames(outcomes, names, sampleTimes(query))
+	}
+
+	if len(frames) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames, executed...),
+	}
+}
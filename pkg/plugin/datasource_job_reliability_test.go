@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeJobReliability(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	outcome := func(hour int, failed bool) runOutcome {
+		start := from.Add(time.Duration(hour) * time.Hour)
+		return runOutcome{Start: start, End: start.Add(10 * time.Minute), Failed: failed}
+	}
+
+	outcomes := []runOutcome{
+		outcome(0, false),
+		outcome(1, true),
+		outcome(2, true),
+		outcome(3, false),
+		outcome(5, true),
+		outcome(6, false),
+		outcome(7, true),
+		outcome(8, true),
+	}
+
+	result := computeJobReliability(outcomes)
+	if result.Runs != 8 || result.Failures != 5 || result.Episodes != 3 {
+		t.Errorf("unexpected counts: %+v", result)
+	}
+
+	// recoveries after 2h and 1h
+	if *result.MTTR != 1.5*3600 {
+		t.Errorf("unexpected MTTR: %v", *result.MTTR)
+	}
+
+	// episodes started at 1h, 5h and 7h
+	if *result.MTBF != 3*3600 {
+		t.Errorf("unexpected MTBF: %v", *result.MTBF)
+	}
+
+	if result.FailureStreak != 2 || !result.LastSuccess.Equal(from.Add(6*time.Hour+10*time.Minute)) {
+		t.Errorf("unexpected streak or last success: %+v", result)
+	}
+
+	t.Run("should bucket by interval", func(t *testing.T) {
+		times := []time.Time{from, from.Add(4 * time.Hour)}
+		frames := buildJobReliabilityFrames(map[int64][]runOutcome{1: outcomes}, map[int64]string{1: "job"}, times)
+		if len(frames) != 1 {
+			t.Fatalf("expected 1 frame, got %d", len(frames))
+		}
+
+		failures, _ := frames[0].FieldByName("Failures")
+		if failures.At(0).(int64) != 2 || failures.At(1).(int64) != 3 {
+			t.Errorf("unexpected failures: %v, %v", failures.At(0), failures.At(1))
+		}
+
+		mttr, _ := frames[0].FieldByName("MTTR (seconds)")
+		if *mttr.At(0).(*float64) != 2*3600 || *mttr.At(1).(*float64) != 3600 {
+			t.Errorf("unexpected MTTR: %v, %v", mttr.At(0), mttr.At(1))
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	jobRunLogSourceError          = "error"
+	jobRunLogSourceErrorTrace     = "error_trace"
+	jobRunLogSourceNotebookOutput = "notebook_output"
+	jobRunLogSourceLogs           = "logs"
+)
+
+type jobRunLogsParams struct {
+	RunID string `json:"runId"`
+}
+
+// logEntry is a line of a logs frame.
+type logEntry struct {
+	Time   time.Time
+	Level  string
+	Body   string
+	Labels map[string]string
+}
+
+func parseJobRunLogsParams(_ backend.DataQuery, qm queryModel) (jobRunLogsParams, error) {
+	var params jobRunLogsParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	if params.RunID == "" {
+		return params, fmt.Errorf("run id is required")
+	}
+
+	return params, nil
+}
+
+// jobRunLogEntries returns the log entries of the output of a single task run: its error and
+// error trace, the notebook exit value and the log output, each as a separate entry.
+func jobRunLogEntries(output jobs.RunOutput, taskKey string, runId int64, timestamp time.Time) []logEntry {
+	entries := []logEntry{}
+	add := func(source string, level string, body string, truncated bool) {
+		if body == "" {
+			return
+		}
+
+		labels := map[string]string{
+			"run_id": strconv.FormatInt(runId, 10),
+			"source": source,
+		}
+		if taskKey != "" {
+			labels["task_key"] = taskKey
+		}
+		if truncated {
+			labels["truncated"] = "true"
+		}
+
+		entries = append(entries, logEntry{Time: timestamp, Level: level, Body: body, Labels: labels})
+	}
+
+	add(jobRunLogSourceError, "error", output.Error, false)
+	add(jobRunLogSourceErrorTrace, "error", output.ErrorTrace, false)
+	if output.NotebookOutput != nil {
+		add(jobRunLogSourceNotebookOutput, "info", output.NotebookOutput.Result, output.NotebookOutput.Truncated)
+	}
+	add(jobRunLogSourceLogs, "info", output.Logs, output.LogsTruncated)
+
+	return entries
+}
+
+// jobRunLogTime returns the time of the log entries of a run, which is when it ended, or
+// when it started if it is still running.
+func jobRunLogTime(startTime int64, endTime int64, now time.Time) time.Time {
+	switch {
+	case endTime != 0:
+		return time.UnixMilli(endTime)
+	case startTime != 0:
+		return time.UnixMilli(startTime)
+	default:
+		return now
+	}
+}
+
+// buildLogsFrame returns a frame following the logs data plane contract, so it can be
+// shown in the Logs panel and Explore.
+func buildLogsFrame(name string, entries []logEntry) (*data.Frame, error) {
+	frame := data.NewFrame(name,
+		data.NewField("timestamp", nil, []time.Time{}),
+		data.NewField("body", nil, []string{}),
+		data.NewField("severity", nil, []string{}),
+		data.NewField("labels", nil, []json.RawMessage{}),
+	)
+	frame.SetMeta(&data.FrameMeta{
+		Type:                   data.FrameTypeLogLines,
+		TypeVersion:            data.FrameTypeVersion{0, 0},
+		PreferredVisualization: data.VisTypeLogs,
+	})
+
+	// sort results ascending by Time, keeping the order of the entries of a task
+	slices.SortStableFunc(entries, func(i, j logEntry) int {
+		return cmp.Compare(i.Time.UnixMilli(), j.Time.UnixMilli())
+	})
+
+	for _, entry := range entries {
+		labels, err := json.Marshal(entry.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		frame.AppendRow(entry.Time, entry.Body, entry.Level, json.RawMessage(labels))
+	}
+
+	return frame, nil
+}
+
+func (d *Datasource) queryJobRunLogs(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseJobRunLogsParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	runId, err := strconv.ParseInt(params.RunID, 10, 64)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid run id: %s", params.RunID))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	run, err := w.Jobs.GetRun(ctx, jobs.GetRunRequest{RunId: runId})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get run: %v", err))
+	}
+
+	// the output of a multi-task run is only available per task
+	type taskRun struct {
+		taskKey string
+		runId   int64
+		time    time.Time
+	}
+
+	now := time.Now()
+	taskRuns := []taskRun{}
+	for _, task := range run.Tasks {
+		taskRuns = append(taskRuns, taskRun{task.TaskKey, task.RunId, jobRunLogTime(task.StartTime, task.EndTime, now)})
+	}
+
+	if len(taskRuns) == 0 {
+		taskRuns = append(taskRuns, taskRun{"", run.RunId, jobRunLogTime(run.StartTime, run.EndTime, now)})
+	}
+
+	entries := []logEntry{}
+	notices := []data.Notice{}
+	executed := []string{describeRequest("GET", "/api/2.2/jobs/runs/get", jobs.GetRunRequest{RunId: runId})}
+	for _, task := range taskRuns {
+		request := jobs.GetRunOutputRequest{RunId: task.runId}
+		executed = append(executed, describeRequest("GET", "/api/2.2/jobs/runs/get-output", request))
+
+		output, err := w.Jobs.GetRunOutput(ctx, request)
+		if err != nil {
+			// tasks that were skipped or haven't started have no output
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("failed to get output of run %d: %v", task.runId, err),
+			})
+			continue
+		}
+
+		entries = append(entries, jobRunLogEntries(*output, task.taskKey, task.runId, task.time)...)
+	}
+
+	frame, err := buildLogsFrame("Databricks Job Run Logs", entries)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to build logs frame: %v", err))
+	}
+
+	frame.AppendNotices(notices...)
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, executed...),
+	}
+}
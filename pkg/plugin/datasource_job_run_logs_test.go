@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestJobRunLogs(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	failed := jobRunLogEntries(jobs.RunOutput{
+		Error:      "ZeroDivisionError: division by zero",
+		ErrorTrace: "Traceback (most recent call last): ...",
+	}, "transform", 12, start.Add(time.Hour))
+	succeeded := jobRunLogEntries(jobs.RunOutput{
+		NotebookOutput: &jobs.NotebookOutput{Result: "42 rows", Truncated: true},
+		Logs:           "done",
+	}, "ingest", 11, start)
+
+	if len(failed) != 2 || failed[0].Level != "error" || failed[1].Labels["source"] != jobRunLogSourceErrorTrace {
+		t.Fatalf("expected error and error trace entries, got %+v", failed)
+	}
+
+	if len(succeeded) != 2 || succeeded[0].Labels["truncated"] != "true" || succeeded[1].Labels["source"] != jobRunLogSourceLogs {
+		t.Fatalf("expected notebook output and logs entries, got %+v", succeeded)
+	}
+
+	if empty := jobRunLogEntries(jobs.RunOutput{}, "", 1, start); len(empty) != 0 {
+		t.Errorf("expected no entries for an empty output, got %+v", empty)
+	}
+
+	frame, err := buildLogsFrame("Databricks Job Run Logs", append(failed, succeeded...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if frame.Meta.Type != data.FrameTypeLogLines {
+		t.Errorf("expected log lines frame, got %s", frame.Meta.Type)
+	}
+
+	if rows, _ := frame.RowLen(); rows != 4 {
+		t.Fatalf("expected 4 rows, got %d", rows)
+	}
+
+	// entries are sorted by time, the ingest task ended first
+	var labels map[string]string
+	if err := json.Unmarshal(frame.Fields[3].At(0).(json.RawMessage), &labels); err != nil {
+		t.Fatal(err)
+	}
+
+	if labels["task_key"] != "ingest" || labels["run_id"] != "11" {
+		t.Errorf("expected ingest task first, got %v", labels)
+	}
+
+	if body := frame.Fields[1].At(2).(string); body != "ZeroDivisionError: division by zero" {
+		t.Errorf("expected error body, got %q", body)
+	}
+
+	if got := jobRunLogTime(start.UnixMilli(), 0, time.Now()); !got.Equal(start) {
+		t.Errorf("expected start time for a running task, got %v", got)
+	}
+}
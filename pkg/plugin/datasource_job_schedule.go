@@ -0,0 +1,311 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// a scheduled fire time without a matching run is missed once this has passed
+	defaultMissedAfter = 15 * time.Minute
+
+	defaultMaxFireTimes = 100
+
+	scheduleStatusUpcoming = "upcoming"
+	scheduleStatusPending  = "pending"
+	scheduleStatusStarted  = "started"
+	scheduleStatusMissed   = "missed"
+)
+
+type scheduledJob struct {
+	ID       int64
+	Name     string
+	Schedule jobs.CronSchedule
+}
+
+type scheduledFire struct {
+	Job         scheduledJob
+	Expected    time.Time
+	Status      string
+	RunID       *int64
+	ActualStart *time.Time
+}
+
+// scheduledJobFrom returns the schedule of a job, or false when the job has no active cron
+// schedule.
+func scheduledJobFrom(jobId int64, settings *jobs.JobSettings) (scheduledJob, bool) {
+	if settings == nil || settings.Schedule == nil || settings.Schedule.PauseStatus == jobs.PauseStatusPaused {
+		return scheduledJob{}, false
+	}
+
+	return scheduledJob{ID: jobId, Name: settings.Name, Schedule: *settings.Schedule}, true
+}
+
+// fetchScheduledJobs returns the job of the job id filter, or up to maxJobs jobs with an
+// active schedule.
+func fetchScheduledJobs(ctx context.Context, w *databricks.WorkspaceClient, params jobRunParams, maxJobs int) ([]scheduledJob, error) {
+	scheduled := []scheduledJob{}
+
+	if params.JobID != "" {
+		jobId, err := strconv.ParseInt(params.JobID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		job, err := w.Jobs.GetByJobId(ctx, jobId)
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := scheduledJobFrom(job.JobId, job.Settings); ok {
+			scheduled = append(scheduled, s)
+		}
+
+		return scheduled, nil
+	}
+
+	all, err := fetchMatching(ctx, w.Jobs.List(ctx, jobs.ListJobsRequest{Limit: 100}), maxJobs, func(job jobs.BaseJob) bool {
+		_, ok := scheduledJobFrom(job.JobId, job.Settings)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range all {
+		s, _ := scheduledJobFrom(job.JobId, job.Settings)
+		scheduled = append(scheduled, s)
+	}
+
+	return scheduled, nil
+}
+
+// forecastJobSchedule computes the fire times of each job within [from, to] and matches them
+// with the periodic runs of that job. A run matches the earliest unmatched fire time it
+// started at or after (allowing for a minute of clock skew), within missedAfter.
+func forecastJobSchedule(scheduled []scheduledJob, runs []jobs.BaseRun, from time.Time, to time.Time, missedAfter time.Duration, maxFireTimes int, now time.Time) ([]scheduledFire, error) {
+	runsByJob := map[int64][]jobs.BaseRun{}
+	for _, run := range runs {
+		if run.Trigger != jobs.TriggerTypePeriodic {
+			continue
+		}
+
+		runsByJob[run.JobId] = append(runsByJob[run.JobId], run)
+	}
+
+	fires := []scheduledFire{}
+	for _, job := range scheduled {
+		schedule, err := parseCronSchedule(job.Schedule.QuartzCronExpression, job.Schedule.TimezoneId)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %v", job.ID, err)
+		}
+
+		jobRuns := runsByJob[job.ID]
+		slices.SortFunc(jobRuns, func(i, j jobs.BaseRun) int {
+			return cmp.Compare(i.StartTime, j.StartTime)
+		})
+
+		used := make([]bool, len(jobRuns))
+		for _, expected := range schedule.between(from, to, maxFireTimes) {
+			fire := scheduledFire{Job: job, Expected: expected}
+
+			for i, run := range jobRuns {
+				start := time.UnixMilli(run.StartTime)
+				if used[i] || start.Before(expected.Add(-time.Minute)) || !start.Before(expected.Add(missedAfter)) {
+					continue
+				}
+
+				used[i] = true
+				fire.RunID = &jobRuns[i].RunId
+				fire.ActualStart = &start
+				break
+			}
+
+			switch {
+			case fire.RunID != nil:
+				fire.Status = scheduleStatusStarted
+			case expected.After(now):
+				fire.Status = scheduleStatusUpcoming
+			case now.Before(expected.Add(missedAfter)):
+				fire.Status = scheduleStatusPending
+			default:
+				fire.Status = scheduleStatusMissed
+			}
+
+			fires = append(fires, fire)
+		}
+	}
+
+	slices.SortStableFunc(fires, func(i, j scheduledFire) int {
+		return i.Expected.Compare(j.Expected)
+	})
+
+	return fires, nil
+}
+
+func buildJobScheduleFrame(fires []scheduledFire, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Job Schedule",
+		data.NewField("Expected Time", nil, []time.Time{}),
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		data.NewField("Job Name", nil, []string{}),
+		data.NewField("Cron Expression", nil, []string{}),
+		data.NewField("Timezone", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		withLinks(data.NewField("Run ID", nil, []*int64{}), jobRunLink(host)),
+		data.NewField("Actual Start Time", nil, []*time.Time{}),
+		data.NewField("Start Delay (milliseconds)", nil, []*int64{}),
+	)
+
+	for _, fire := range fires {
+		var delay *int64
+		if fire.ActualStart != nil {
+			delay = optionalInt64(fire.ActualStart.Sub(fire.Expected).Milliseconds())
+		}
+
+		frame.AppendRow(
+			fire.Expected,
+			fire.Job.ID,
+			fire.Job.Name,
+			fire.Job.Schedule.QuartzCronExpression,
+			fire.Job.Schedule.TimezoneId,
+			fire.Status,
+			fire.RunID,
+			fire.ActualStart,
+			delay,
+		)
+	}
+
+	return frame
+}
+
+// buildJobScheduleTimelineFrames returns the schedule of every job as a state timeline: each
+// fire time is shown with its status until the next fire time of the job.
+func buildJobScheduleTimelineFrames(fires []scheduledFire, from time.Time) []*data.Frame {
+	spans := map[int64][]timelineSpan{}
+	names := map[int64]string{}
+	for _, fire := range fires {
+		jobSpans := spans[fire.Job.ID]
+		if len(jobSpans) > 0 {
+			end := fire.Expected
+			jobSpans[len(jobSpans)-1].End = &end
+		}
+
+		spans[fire.Job.ID] = append(jobSpans, timelineSpan{Start: fire.Expected, State: fire.Status})
+		names[fire.Job.ID] = fire.Job.Name
+	}
+
+	frames := []*data.Frame{}
+	for jobId, jobSpans := range spans {
+		labels := data.Labels{"job_id": strconv.FormatInt(jobId, 10)}
+		frames = append(frames, buildTimelineFrame(names[jobId], labels, jobSpans, from))
+	}
+
+	return sortTimelineFrames(frames)
+}
+
+func (d *Datasource) queryJobSchedule(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel, params jobRunParams) backend.DataResponse {
+	if params.Format != jobRunFormatTable && params.Format != jobRunFormatTimeline {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("format %s is not supported in schedule mode", params.Format))
+	}
+
+	missedAfter := defaultMissedAfter
+	if params.MissedAfter != "" {
+		var err error
+		missedAfter, err = time.ParseDuration(params.MissedAfter)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid missedAfter: %v", err))
+		}
+	}
+
+	maxFireTimes := params.MaxFireTimes
+	if maxFireTimes <= 0 {
+		maxFireTimes = defaultMaxFireTimes
+	}
+
+	now := time.Now()
+	from, to := query.TimeRange.From, query.TimeRange.To
+	if from.IsZero() || to.IsZero() {
+		from, to = now, now.Add(24*time.Hour)
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	scheduled, err := fetchScheduledJobs(ctx, w, params, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch jobs: %v", err))
+	}
+
+	executed := []string{}
+	if params.JobID == "" {
+		executed = append(executed, describeRequest("GET", "/api/2.2/jobs/list", jobs.ListJobsRequest{Limit: 100}))
+	}
+
+	// runs may start up to missedAfter after the last fire time in range
+	request, err := buildListRunsRequest(params, backend.DataQuery{
+		TimeRange: backend.TimeRange{From: from.Add(-time.Minute), To: to.Add(missedAfter)},
+	})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build list runs request: %v", err))
+	}
+
+	// the runs are listed per job, so the runs of a busy job don't crowd out those of the others
+	jobIds := []int64{}
+	for _, job := range scheduled {
+		jobIds = append(jobIds, job.ID)
+		request.JobId = job.ID
+		executed = append(executed, describeListRuns(request))
+	}
+
+	jobsService := &workspaceClientWrapper{client: w}
+	perJob, err := fetchRunsPerJob(ctx, jobsService, request, jobIds, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch job runs: %v", err))
+	}
+
+	notices := []data.Notice{}
+	if params.JobID == "" && len(scheduled) >= qm.Limit {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the first %d scheduled jobs are shown, increase Max Results to see the rest", qm.Limit),
+		})
+	}
+
+	for i, runs := range perJob {
+		if len(runs) >= qm.Limit {
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("run limit of %d reached for job %d, some of its schedules may be reported as missed", qm.Limit, jobIds[i]),
+			})
+		}
+	}
+
+	fires, err := forecastJobSchedule(scheduled, slices.Concat(perJob...), from, to, missedAfter, maxFireTimes, now)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to forecast job schedule: %v", err))
+	}
+
+	frames := []*data.Frame{buildJobScheduleFrame(fires, w.Config.Host)}
+	if params.Format == jobRunFormatTimeline {
+		frames = buildJobScheduleTimelineFrames(fires, from)
+	}
+
+	if len(frames) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames, executed...),
+	}
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/listing"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+)
+
+// runsByJobService returns the runs of the requested job.
+type runsByJobService map[int64][]jobs.BaseRun
+
+func (s runsByJobService) ListRuns(_ context.Context, request jobs.ListRunsRequest) listing.Iterator[jobs.BaseRun] {
+	it := listing.SliceIterator[jobs.BaseRun](s[request.JobId])
+	return &it
+}
+
+func TestForecastJobSchedule(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := from.Add(3*time.Hour + 5*time.Minute)
+	scheduled := []scheduledJob{{
+		ID:       1,
+		Name:     "hourly",
+		Schedule: jobs.CronSchedule{QuartzCronExpression: "0 0 * * * ?", TimezoneId: "UTC"},
+	}}
+
+	runs := []jobs.BaseRun{
+		{JobId: 1, RunId: 10, Trigger: jobs.TriggerTypePeriodic, StartTime: from.Add(20 * time.Second).UnixMilli()},
+		{JobId: 1, RunId: 11, Trigger: jobs.TriggerTypeOneTime, StartTime: from.Add(time.Hour).UnixMilli()},
+		{JobId: 1, RunId: 12, Trigger: jobs.TriggerTypePeriodic, StartTime: from.Add(2*time.Hour + time.Minute).UnixMilli()},
+		{JobId: 2, RunId: 13, Trigger: jobs.TriggerTypePeriodic, StartTime: from.Add(3 * time.Hour).UnixMilli()},
+	}
+
+	fires, err := forecastJobSchedule(scheduled, runs, from, from.Add(4*time.Hour), 15*time.Minute, 100, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		scheduleStatusStarted,
+		scheduleStatusMissed,
+		scheduleStatusStarted,
+		scheduleStatusPending,
+		scheduleStatusUpcoming,
+	}
+
+	if len(fires) != len(expected) {
+		t.Fatalf("expected %d fire times, got %d", len(expected), len(fires))
+	}
+
+	for i, status := range expected {
+		if fires[i].Status != status {
+			t.Errorf("fire time %d: expected %s, got %s", i, status, fires[i].Status)
+		}
+	}
+
+	frame := buildJobScheduleFrame(fires, testHost)
+	delay, _ := frame.FieldByName("Start Delay (milliseconds)")
+	if *delay.At(2).(*int64) != time.Minute.Milliseconds() || delay.At(1).(*int64) != nil {
+		t.Error("unexpected start delays")
+	}
+}
+
+func TestFetchRunsPerJob(t *testing.T) {
+	t.Parallel()
+
+	service := runsByJobService{
+		1: {{JobId: 1, RunId: 10}, {JobId: 1, RunId: 11}, {JobId: 1, RunId: 12}},
+		2: {{JobId: 2, RunId: 20}},
+	}
+
+	runs, err := fetchRunsPerJob(context.Background(), service, jobs.ListRunsRequest{}, []int64{2, 1, 3}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runs) != 3 || len(runs[0]) != 1 || len(runs[1]) != 2 || len(runs[2]) != 0 {
+		t.Fatalf("expected the runs of each job up to the limit, got %v", runs)
+	}
+
+	if runs[0][0].RunId != 20 || runs[1][1].RunId != 11 {
+		t.Errorf("unexpected runs: %v", runs)
+	}
+}
+
+func TestBuildJobScheduleTimelineFrames(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	hourly := scheduledJob{ID: 1, Name: "hourly"}
+	daily := scheduledJob{ID: 2, Name: "daily"}
+
+	fires := []scheduledFire{
+		{Job: hourly, Expected: from.Add(time.Hour), Status: scheduleStatusStarted},
+		{Job: daily, Expected: from.Add(90 * time.Minute), Status: scheduleStatusMissed},
+		{Job: hourly, Expected: from.Add(2 * time.Hour), Status: scheduleStatusUpcoming},
+	}
+
+	frames := buildJobScheduleTimelineFrames(fires, from)
+	if len(frames) != 2 || frames[0].Name != "daily" || frames[1].Name != "hourly" {
+		t.Fatalf("expected a frame per job sorted by name, got %d", len(frames))
+	}
+
+	hourlyFrame := frames[1]
+	if hourlyFrame.Fields[1].Labels["job_id"] != "1" {
+		t.Errorf("unexpected labels: %v", hourlyFrame.Fields[1].Labels)
+	}
+
+	expected := []string{timelineIdle, scheduleStatusStarted, scheduleStatusUpcoming}
+	if hourlyFrame.Rows() != len(expected) {
+		t.Fatalf("expected %d transitions, got %d", len(expected), hourlyFrame.Rows())
+	}
+
+	for i, state := range expected {
+		if hourlyFrame.Fields[1].At(i).(string) != state {
+			t.Errorf("transition %d: expected %s, got %s", i, state, hourlyFrame.Fields[1].At(i))
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/rayalex/databricks/pkg/models"
+)
+
+const (
+	slaStatusOnTime       = "on_time"
+	slaStatusLate         = "late"
+	slaStatusMissed       = "missed"
+	slaStatusOverDuration = "over_duration"
+
+	// numeric outputs of the sla mode, for alert rules
+	slaMetricBreached     = "breached"
+	slaMetricTimeToBreach = "time_to_breach"
+
+	// number of most recent runs fetched per job to evaluate its SLA
+	slaRunsPerJob = 25
+)
+
+type jobSLA struct {
+	Name        string
+	JobID       int64
+	TagKey      string
+	TagValue    *string
+	Cadence     time.Duration
+	MaxDuration time.Duration
+}
+
+type jobSLAResult struct {
+	JobID        int64
+	JobName      string
+	SLA          jobSLA
+	Status       string
+	LastStart    *time.Time
+	LastState    string
+	LastDuration *int64
+	LastSuccess  *time.Time
+	NextDue      *time.Time
+	TimeToBreach *float64
+}
+
+// parseJobSLAs converts the declared SLAs, which need a job id or tag and at least one of
+// cadence and max duration.
+func parseJobSLAs(declared []models.JobSLA) ([]jobSLA, error) {
+	slas := []jobSLA{}
+	for _, d := range declared {
+		var sla jobSLA
+
+		switch {
+		case d.JobID != "":
+			jobId, err := strconv.ParseInt(d.JobID, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid job id %q", d.JobID)
+			}
+
+			sla.JobID = jobId
+			sla.Name = "job:" + d.JobID
+		case d.Tag != "":
+			key, value, hasValue := strings.Cut(d.Tag, "=")
+			sla.TagKey = key
+			if hasValue {
+				sla.TagValue = &value
+			}
+
+			sla.Name = "tag:" + d.Tag
+		default:
+			return nil, fmt.Errorf("SLA requires a job id or tag")
+		}
+
+		if d.Cadence != "" {
+			cadence, err := time.ParseDuration(d.Cadence)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cadence of %s: %v", sla.Name, err)
+			}
+
+			sla.Cadence = cadence
+		}
+
+		if d.MaxDuration != "" {
+			maxDuration, err := time.ParseDuration(d.MaxDuration)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max duration of %s: %v", sla.Name, err)
+			}
+
+			sla.MaxDuration = maxDuration
+		}
+
+		if sla.Cadence <= 0 && sla.MaxDuration <= 0 {
+			return nil, fmt.Errorf("SLA %s requires a cadence or max duration", sla.Name)
+		}
+
+		slas = append(slas, sla)
+	}
+
+	return slas, nil
+}
+
+// matches reports whether the SLA applies to the job.
+func (s jobSLA) matches(job jobs.BaseJob) bool {
+	if s.JobID != 0 {
+		return job.JobId == s.JobID
+	}
+
+	if job.Settings == nil {
+		return false
+	}
+
+	value, ok := job.Settings.Tags[s.TagKey]
+	return ok && (s.TagValue == nil || *s.TagValue == value)
+}
+
+// resolveJobSLAs returns the SLA of every matching job. A job id SLA takes precedence over
+// tag SLAs, otherwise the first declared SLA wins.
+func resolveJobSLAs(slas []jobSLA, allJobs []jobs.BaseJob) map[int64]jobSLA {
+	resolved := map[int64]jobSLA{}
+	for _, job := range allJobs {
+		for _, sla := range slas {
+			if !sla.matches(job) {
+				continue
+			}
+
+			if existing, ok := resolved[job.JobId]; !ok || (existing.JobID == 0 && sla.JobID != 0) {
+				resolved[job.JobId] = sla
+			}
+		}
+	}
+
+	return resolved
+}
+
+// evaluateJobSLA checks the most recent runs of a job against its SLA. The next run is due
+// one cadence after the last run started; it is late once that has passed, and missed once a
+// whole cadence has passed on top. A run is over duration when it ran (or has been running)
+// longer than the max duration. Time to breach is the time left until the next violation,
+// zero when already breached.
+func evaluateJobSLA(sla jobSLA, runs []jobs.BaseRun, now time.Time) jobSLAResult {
+	result := jobSLAResult{SLA: sla, Status: slaStatusOnTime}
+
+	slices.SortFunc(runs, func(i, j jobs.BaseRun) int {
+		return cmp.Compare(j.StartTime, i.StartTime)
+	})
+
+	for _, run := range runs {
+		if runSucceeded(run) {
+			result.LastSuccess = optionalUnixMilli(run.StartTime)
+			break
+		}
+	}
+
+	var deadlines []time.Time
+	if len(runs) == 0 {
+		if sla.Cadence > 0 {
+			result.Status = slaStatusMissed
+			result.TimeToBreach = new(float64)
+		}
+
+		return result
+	}
+
+	last := runs[0]
+	lastStart := time.UnixMilli(last.StartTime)
+	result.LastStart = &lastStart
+	result.LastState = runResultState(last)
+	if runActive(last) {
+		result.LastState = "RUNNING"
+	}
+
+	duration := now.Sub(lastStart)
+	if !runActive(last) {
+		duration = time.UnixMilli(last.EndTime).Sub(lastStart)
+	}
+	result.LastDuration = optionalInt64(duration.Milliseconds())
+
+	statuses := []string{}
+	if sla.Cadence > 0 {
+		due := lastStart.Add(sla.Cadence)
+		result.NextDue = &due
+		deadlines = append(deadlines, due)
+
+		switch {
+		case now.After(due.Add(sla.Cadence)):
+			statuses = append(statuses, slaStatusMissed)
+		case now.After(due):
+			statuses = append(statuses, slaStatusLate)
+		}
+	}
+
+	if sla.MaxDuration > 0 {
+		if duration > sla.MaxDuration {
+			statuses = append(statuses, slaStatusOverDuration)
+		} else if runActive(last) {
+			deadlines = append(deadlines, lastStart.Add(sla.MaxDuration))
+		}
+	}
+
+	// the most severe violation wins
+	for _, status := range []string{slaStatusMissed, slaStatusLate, slaStatusOverDuration} {
+		if slices.Contains(statuses, status) {
+			result.Status = status
+			break
+		}
+	}
+
+	if len(statuses) > 0 {
+		result.TimeToBreach = new(float64)
+	} else if len(deadlines) > 0 {
+		timeToBreach := slices.MinFunc(deadlines, time.Time.Compare).Sub(now).Seconds()
+		result.TimeToBreach = &timeToBreach
+	}
+
+	return result
+}
+
+func buildJobSLAFrame(results []jobSLAResult, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Job SLA",
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		data.NewField("Job Name", nil, []string{}),
+		data.NewField("SLA", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		data.NewField("Cadence", nil, []string{}),
+		data.NewField("Max Duration", nil, []string{}),
+		data.NewField("Last Run Start", nil, []*time.Time{}),
+		data.NewField("Last Run State", nil, []string{}),
+		data.NewField("Last Run Duration (milliseconds)", nil, []*int64{}),
+		data.NewField("Last Success", nil, []*time.Time{}),
+		data.NewField("Next Run Due", nil, []*time.Time{}),
+		data.NewField("Time To Breach (seconds)", nil, []*float64{}),
+	)
+
+	formatDuration := func(d time.Duration) string {
+		if d <= 0 {
+			return ""
+		}
+
+		return d.String()
+	}
+
+	for _, result := range results {
+		frame.AppendRow(
+			result.JobID,
+			result.JobName,
+			result.SLA.Name,
+			result.Status,
+			formatDuration(result.SLA.Cadence),
+			formatDuration(result.SLA.MaxDuration),
+			result.LastStart,
+			result.LastState,
+			result.LastDuration,
+			result.LastSuccess,
+			result.NextDue,
+			result.TimeToBreach,
+		)
+	}
+
+	return frame
+}
+
+// buildJobSLANumericFrames returns one numeric frame per job, so each job becomes a separate
+// alert instance: 1 when the SLA is breached and 0 otherwise, or the seconds until it is. The
+// labels only identify the job, so an alert instance keeps its identity when the status changes.
+func buildJobSLANumericFrames(results []jobSLAResult, metric string) []*data.Frame {
+	frames := []*data.Frame{}
+	for _, result := range results {
+		labels := data.Labels{
+			"job_id":   strconv.FormatInt(result.JobID, 10),
+			"job_name": result.JobName,
+			"sla":      result.SLA.Name,
+		}
+
+		var field *data.Field
+		if metric == slaMetricTimeToBreach {
+			if result.TimeToBreach == nil {
+				continue
+			}
+
+			field = data.NewField("Time To Breach (seconds)", labels, []float64{*result.TimeToBreach})
+		} else {
+			var breached float64
+			if result.Status != slaStatusOnTime {
+				breached = 1
+			}
+
+			field = data.NewField("SLA Breached", labels, []float64{breached})
+		}
+
+		frame := data.NewFrame(result.JobName, field)
+		frame.SetMeta(&data.FrameMeta{Type: data.FrameTypeNumericMulti})
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryJobSLA(ctx context.Context, pCtx backend.PluginContext, qm queryModel, params jobRunParams) backend.DataResponse {
+	switch params.Format {
+	case jobRunFormatTable:
+	case jobRunFormatNumeric:
+		// the numeric format returns the breached series, unless another metric is selected
+		if params.SLAMetric == "" {
+			params.SLAMetric = slaMetricBreached
+		}
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("format %s is not supported in sla mode", params.Format))
+	}
+
+	switch params.SLAMetric {
+	case "", slaMetricBreached, slaMetricTimeToBreach:
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown SLA metric: %s", params.SLAMetric))
+	}
+
+	declared := params.SLAs
+	if len(declared) == 0 {
+		config, err := models.LoadPluginSettings(d.settings)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("load plugin settings: %v", err))
+		}
+
+		declared = config.JobSLAs
+	}
+
+	slas, err := parseJobSLAs(declared)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse SLAs: %v", err))
+	}
+
+	if len(slas) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "no SLAs declared in the query or datasource settings")
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	var allJobs []jobs.BaseJob
+	executed := []string{}
+	if params.JobID != "" {
+		jobId, err := strconv.ParseInt(params.JobID, 10, 64)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid job id: %s", params.JobID))
+		}
+
+		job, err := w.Jobs.GetByJobId(ctx, jobId)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get job %d: %v", jobId, err))
+		}
+
+		allJobs = []jobs.BaseJob{{JobId: job.JobId, Settings: job.Settings}}
+		executed = append(executed, describeRequest("GET", "/api/2.2/jobs/get", jobs.GetJobRequest{JobId: jobId}))
+	} else {
+		listJobsRequest := jobs.ListJobsRequest{Limit: 100}
+		allJobs, err = fetchMatching(ctx, w.Jobs.List(ctx, listJobsRequest), qm.Limit, func(job jobs.BaseJob) bool {
+			return slices.ContainsFunc(slas, func(sla jobSLA) bool { return sla.matches(job) })
+		})
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list jobs: %v", err))
+		}
+
+		executed = append(executed, describeRequest("GET", "/api/2.2/jobs/list", listJobsRequest))
+	}
+
+	resolved := resolveJobSLAs(slas, allJobs)
+	request := jobs.ListRunsRequest{Limit: slaRunsPerJob}
+	jobIds := []int64{}
+	for _, job := range allJobs {
+		if _, ok := resolved[job.JobId]; ok {
+			jobIds = append(jobIds, job.JobId)
+			request.JobId = job.JobId
+			executed = append(executed, describeListRuns(request))
+		}
+	}
+
+	jobsService := &workspaceClientWrapper{client: w}
+	perJob, err := fetchRunsPerJob(ctx, jobsService, request, jobIds, slaRunsPerJob)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch job runs: %v", err))
+	}
+
+	now := time.Now()
+	results := []jobSLAResult{}
+	for _, job := range allJobs {
+		i := slices.Index(jobIds, job.JobId)
+		if i < 0 {
+			continue
+		}
+
+		result := evaluateJobSLA(resolved[job.JobId], perJob[i], now)
+		result.JobID = job.JobId
+		if job.Settings != nil {
+			result.JobName = job.Settings.Name
+		}
+
+		results = append(results, result)
+	}
+
+	notices := []data.Notice{}
+	if params.JobID == "" && len(allJobs) >= qm.Limit {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the first %d jobs with an SLA are evaluated, increase Max Results to see the rest", qm.Limit),
+		})
+	}
+
+	frames := []*data.Frame{buildJobSLAFrame(results, w.Config.Host)}
+	if params.SLAMetric != "" {
+		frames = buildJobSLANumericFrames(results, params.SLAMetric)
+	}
+
+	if len(frames) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames, executed...),
+	}
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/rayalex/databricks/pkg/models"
+)
+
+func TestParseJobSLAs(t *testing.T) {
+	t.Parallel()
+
+	slas, err := parseJobSLAs([]models.JobSLA{
+		{JobID: "42", Cadence: "1h"},
+		{Tag: "team=etl", MaxDuration: "30m"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if slas[0].JobID != 42 || slas[0].Cadence != time.Hour || slas[0].Name != "job:42" {
+		t.Errorf("unexpected job SLA: %+v", slas[0])
+	}
+
+	if slas[1].TagKey != "team" || *slas[1].TagValue != "etl" || slas[1].MaxDuration != 30*time.Minute {
+		t.Errorf("unexpected tag SLA: %+v", slas[1])
+	}
+
+	invalid := [][]models.JobSLA{
+		{{Cadence: "1h"}},
+		{{JobID: "42"}},
+		{{JobID: "42", Cadence: "daily"}},
+	}
+
+	for _, declared := range invalid {
+		if _, err := parseJobSLAs(declared); err == nil {
+			t.Errorf("expected error for %+v", declared)
+		}
+	}
+}
+
+func TestResolveJobSLAs(t *testing.T) {
+	t.Parallel()
+
+	etl := "etl"
+	slas := []jobSLA{
+		{Name: "tag:team=etl", TagKey: "team", TagValue: &etl},
+		{Name: "job:2", JobID: 2},
+	}
+
+	allJobs := []jobs.BaseJob{
+		{JobId: 1, Settings: &jobs.JobSettings{Tags: map[string]string{"team": "etl"}}},
+		{JobId: 2, Settings: &jobs.JobSettings{Tags: map[string]string{"team": "etl"}}},
+		{JobId: 3, Settings: &jobs.JobSettings{Tags: map[string]string{"team": "ml"}}},
+	}
+
+	resolved := resolveJobSLAs(slas, allJobs)
+	if len(resolved) != 2 || resolved[1].Name != "tag:team=etl" || resolved[2].Name != "job:2" {
+		t.Errorf("unexpected resolved SLAs: %+v", resolved)
+	}
+}
+
+func TestEvaluateJobSLA(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sla := jobSLA{Name: "job:1", Cadence: time.Hour, MaxDuration: 10 * time.Minute}
+	finished := func(start time.Duration, duration time.Duration) jobs.BaseRun {
+		return jobs.BaseRun{
+			StartTime: now.Add(-start).UnixMilli(),
+			EndTime:   now.Add(-start + duration).UnixMilli(),
+			State:     &jobs.RunState{ResultState: jobs.RunResultStateSuccess},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		runs         []jobs.BaseRun
+		status       string
+		timeToBreach float64
+	}{
+		{"on time", []jobs.BaseRun{finished(30*time.Minute, 5*time.Minute)}, slaStatusOnTime, 30 * 60},
+		{"late", []jobs.BaseRun{finished(90*time.Minute, 5*time.Minute)}, slaStatusLate, 0},
+		{"missed", []jobs.BaseRun{finished(3*time.Hour, 5*time.Minute)}, slaStatusMissed, 0},
+		{"over duration", []jobs.BaseRun{finished(30*time.Minute, 15*time.Minute)}, slaStatusOverDuration, 0},
+		{"running", []jobs.BaseRun{{StartTime: now.Add(-4 * time.Minute).UnixMilli()}}, slaStatusOnTime, 6 * 60},
+		{"no runs", nil, slaStatusMissed, 0},
+	}
+
+	for _, test := range tests {
+		result := evaluateJobSLA(sla, test.runs, now)
+		if result.Status != test.status {
+			t.Errorf("%s: expected status %s, got %s", test.name, test.status, result.Status)
+		}
+
+		if result.TimeToBreach == nil || *result.TimeToBreach != test.timeToBreach {
+			t.Errorf("%s: expected time to breach %v, got %v", test.name, test.timeToBreach, result.TimeToBreach)
+		}
+	}
+
+	t.Run("should return alertable frames", func(t *testing.T) {
+		results := []jobSLAResult{
+			evaluateJobSLA(sla, []jobs.BaseRun{finished(90*time.Minute, 5*time.Minute)}, now),
+		}
+
+		frames := buildJobSLANumericFrames(results, slaMetricBreached)
+		if len(frames) != 1 || frames[0].Fields[0].At(0).(float64) != 1 {
+			t.Error("expected breached SLA")
+		}
+
+		if _, ok := frames[0].Fields[0].Labels["status"]; ok {
+			t.Errorf("expected no status label, got %v", frames[0].Fields[0].Labels)
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	jobRunStreamPrefix   = "jobs"
+	jobRunStreamInterval = 5 * time.Second
+
+	// maximum number of active runs polled per stream
+	jobRunStreamLimit = 500
+
+	runChangeActive   = "active"
+	runChangeStarted  = "started"
+	runChangeUpdated  = "updated"
+	runChangeFinished = "finished"
+)
+
+// jobRunStreamFilter selects the runs of a stream, from a channel path like jobs,
+// jobs/job/<job id>, jobs/run/<run id> or jobs/type/<run type>.
+type jobRunStreamFilter struct {
+	JobID   int64
+	RunID   int64
+	RunType string
+}
+
+type runSnapshot struct {
+	RunID       int64
+	JobID       int64
+	RunName     string
+	State       string
+	ResultState string
+}
+
+type runChange struct {
+	runSnapshot
+	Change string
+}
+
+func parseJobRunStreamPath(path string) (jobRunStreamFilter, error) {
+	var filter jobRunStreamFilter
+
+	parts := strings.Split(path, "/")
+	if parts[0] != jobRunStreamPrefix {
+		return filter, fmt.Errorf("unknown stream path: %s", path)
+	}
+
+	if len(parts) == 1 {
+		return filter, nil
+	}
+
+	if len(parts) != 3 {
+		return filter, fmt.Errorf("invalid stream path: %s", path)
+	}
+
+	switch parts[1] {
+	case "job", "run":
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid id in stream path: %s", path)
+		}
+
+		if parts[1] == "job" {
+			filter.JobID = id
+		} else {
+			filter.RunID = id
+		}
+	case "type":
+		filter.RunType = parts[2]
+	default:
+		return filter, fmt.Errorf("invalid stream path: %s", path)
+	}
+
+	return filter, nil
+}
+
+// jobRunStreamPath returns the channel path matching the filters of a job runs query.
+func jobRunStreamPath(params jobRunParams) string {
+	switch {
+	case params.JobID != "":
+		return jobRunStreamPrefix + "/job/" + params.JobID
+	case params.RunType != "":
+		return jobRunStreamPrefix + "/type/" + params.RunType
+	default:
+		return jobRunStreamPrefix
+	}
+}
+
+func runSnapshotOf(runId int64, jobId int64, runName string, status *jobs.RunStatus, state *jobs.RunState) runSnapshot {
+	snapshot := runSnapshot{RunID: runId, JobID: jobId, RunName: runName, State: runState(status, state)}
+
+	if state != nil {
+		snapshot.ResultState = string(state.ResultState)
+	}
+
+	return snapshot
+}
+
+// diffRunSnapshots returns the runs that started, changed state or finished between two
+// polls. Runs that are no longer active are reported as finished with their last known state.
+func diffRunSnapshots(previous map[int64]runSnapshot, current map[int64]runSnapshot) []runChange {
+	changes := []runChange{}
+	for _, runId := range slices.Sorted(maps.Keys(current)) {
+		run := current[runId]
+		prev, ok := previous[runId]
+
+		switch {
+		case ok && prev == run:
+			continue
+		case run.ResultState != "":
+			changes = append(changes, runChange{run, runChangeFinished})
+		case !ok:
+			changes = append(changes, runChange{run, runChangeStarted})
+		default:
+			changes = append(changes, runChange{run, runChangeUpdated})
+		}
+	}
+
+	for _, runId := range slices.Sorted(maps.Keys(previous)) {
+		if _, ok := current[runId]; !ok {
+			changes = append(changes, runChange{previous[runId], runChangeFinished})
+		}
+	}
+
+	return changes
+}
+
+func buildRunChangesFrame(changes []runChange, host string, now time.Time) *data.Frame {
+	frame := data.NewFrame("Databricks Job Run Updates",
+		data.NewField("Time", nil, []time.Time{}),
+		withLinks(data.NewField("Run ID", nil, []int64{}), jobRunLink(host)),
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		data.NewField("Run Name", nil, []string{}),
+		data.NewField("State", nil, []string{}),
+		data.NewField("Result State", nil, []string{}),
+		data.NewField("Change", nil, []string{}),
+	)
+
+	for _, change := range changes {
+		frame.AppendRow(
+			now,
+			change.RunID,
+			change.JobID,
+			change.RunName,
+			change.State,
+			change.ResultState,
+			change.Change,
+		)
+	}
+
+	return frame
+}
+
+// fetchStreamRuns returns the active runs matching the filter. A single run is always
+// returned, so its final state is reported when it finishes.
+func fetchStreamRuns(ctx context.Context, w *databricks.WorkspaceClient, filter jobRunStreamFilter) (map[int64]runSnapshot, error) {
+	snapshots := map[int64]runSnapshot{}
+
+	if filter.RunID != 0 {
+		run, err := w.Jobs.GetRun(ctx, jobs.GetRunRequest{RunId: filter.RunID})
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots[run.RunId] = runSnapshotOf(run.RunId, run.JobId, run.RunName, run.Status, run.State)
+		return snapshots, nil
+	}
+
+	request := jobs.ListRunsRequest{
+		ActiveOnly: true,
+		JobId:      filter.JobID,
+		RunType:    jobs.RunType(filter.RunType),
+		Limit:      25,
+	}
+
+	runs, err := fetchJobRuns(ctx, &workspaceClientWrapper{client: w}, request, jobRunStreamLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs {
+		snapshots[run.RunId] = runSnapshotOf(run.RunId, run.JobId, run.RunName, run.Status, run.State)
+	}
+
+	return snapshots, nil
+}
+
+// pollJobRuns returns the poller of a job run stream. It publishes the active runs on the
+// first poll, and the changes since the previous poll after that.
+func (d *Datasource) pollJobRuns(pCtx backend.PluginContext, filter jobRunStreamFilter) streamPollFunc {
+	return func(ctx context.Context, publish func(changes *data.Frame, snapshot *data.Frame)) error {
+		w, err := d.getDatabricksClient(ctx, pCtx)
+		if err != nil {
+			return fmt.Errorf("failed to get databricks client: %w", err)
+		}
+
+		ticker := time.NewTicker(jobRunStreamInterval)
+		defer ticker.Stop()
+
+		var previous map[int64]runSnapshot
+		for {
+			current, err := fetchStreamRuns(ctx, w, filter)
+			if err != nil && previous == nil {
+				return fmt.Errorf("failed to poll job runs: %w", err)
+			} else if err != nil {
+				log.DefaultLogger.Warn("failed to poll job runs", "error", err)
+			} else {
+				now := time.Now()
+
+				active := []runChange{}
+				for _, runId := range slices.Sorted(maps.Keys(current)) {
+					active = append(active, runChange{current[runId], runChangeActive})
+				}
+
+				snapshot := buildRunChangesFrame(active, w.Config.Host, now)
+				if previous == nil {
+					publish(snapshot, snapshot)
+				} else if changes := diffRunSnapshots(previous, current); len(changes) > 0 {
+					// runs that left the active list are looked up for their result state
+					for i, change := range changes {
+						if change.Change != runChangeFinished || change.ResultState != "" {
+							continue
+						}
+
+						if run, err := w.Jobs.GetRun(ctx, jobs.GetRunRequest{RunId: change.RunID}); err == nil {
+							changes[i].runSnapshot = runSnapshotOf(run.RunId, run.JobId, run.RunName, run.Status, run.State)
+						}
+					}
+
+					publish(buildRunChangesFrame(changes, w.Config.Host, now), snapshot)
+				}
+
+				previous = current
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestParseJobRunStreamPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path    string
+		want    jobRunStreamFilter
+		wantErr bool
+	}{
+		{path: "jobs", want: jobRunStreamFilter{}},
+		{path: "jobs/job/42", want: jobRunStreamFilter{JobID: 42}},
+		{path: "jobs/run/7", want: jobRunStreamFilter{RunID: 7}},
+		{path: "jobs/type/SUBMIT_RUN", want: jobRunStreamFilter{RunType: "SUBMIT_RUN"}},
+		{path: "jobs/job/abc", wantErr: true},
+		{path: "jobs/job", wantErr: true},
+		{path: "jobs/cluster/1", wantErr: true},
+		{path: "pipelines", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseJobRunStreamPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: expected error %v, got %v", tt.path, tt.wantErr, err)
+		}
+
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.path, tt.want, got)
+		}
+	}
+
+	if got := jobRunStreamPath(jobRunParams{JobID: "42"}); got != "jobs/job/42" {
+		t.Errorf("expected jobs/job/42, got %s", got)
+	}
+}
+
+func TestDiffRunSnapshots(t *testing.T) {
+	t.Parallel()
+
+	previous := map[int64]runSnapshot{
+		1: {RunID: 1, JobID: 10, State: "RUNNING"},
+		2: {RunID: 2, JobID: 10, State: "PENDING"},
+		3: {RunID: 3, JobID: 20, State: "RUNNING"},
+	}
+	current := map[int64]runSnapshot{
+		1: {RunID: 1, JobID: 10, State: "RUNNING"},
+		2: {RunID: 2, JobID: 10, State: "RUNNING"},
+		4: {RunID: 4, JobID: 20, State: "PENDING"},
+		5: {RunID: 5, JobID: 20, State: "TERMINATED", ResultState: "SUCCESS"},
+	}
+
+	changes := diffRunSnapshots(previous, current)
+	want := []struct {
+		runId  int64
+		change string
+	}{
+		{2, runChangeUpdated},
+		{4, runChangeStarted},
+		{5, runChangeFinished},
+		{3, runChangeFinished},
+	}
+
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+
+	for i, w := range want {
+		if changes[i].RunID != w.runId || changes[i].Change != w.change {
+			t.Errorf("change %d: expected run %d %s, got run %d %s", i, w.runId, w.change, changes[i].RunID, changes[i].Change)
+		}
+	}
+
+	frame := buildRunChangesFrame(changes, testHost, time.Now())
+	if rows, _ := frame.RowLen(); rows != len(want) {
+		t.Errorf("expected %d rows, got %d", len(want), rows)
+	}
+}
+
+func TestStreamPollersShared(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streams := newStreamPollers(ctx)
+	started := make(chan struct{}, 2)
+	stopped := make(chan struct{})
+	poll := func(ctx context.Context, publish func(changes *data.Frame, snapshot *data.Frame)) error {
+		started <- struct{}{}
+		publish(data.NewFrame("changes"), data.NewFrame("snapshot"))
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	}
+
+	first, unsubscribeFirst := streams.subscribe("jobs", poll)
+	if frame := <-first.updates; frame.Name != "changes" {
+		t.Fatalf("expected changes frame, got %s", frame.Name)
+	}
+
+	// a second subscriber shares the poller and gets the snapshot right away
+	second, unsubscribeSecond := streams.subscribe("jobs", poll)
+	if frame := <-second.updates; frame.Name != "snapshot" {
+		t.Fatalf("expected snapshot frame, got %s", frame.Name)
+	}
+
+	if len(started) != 1 {
+		t.Fatalf("expected one poller, got %d", len(started))
+	}
+
+	unsubscribeFirst()
+	select {
+	case <-stopped:
+		t.Fatal("poller stopped while it still has a subscriber")
+	default:
+	}
+
+	unsubscribeSecond()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("poller not stopped after the last subscriber left")
+	}
+
+	<-second.done
+	if err := second.err(); err != nil {
+		t.Errorf("expected no error after unsubscribing, got %v", err)
+	}
+}
+
+func TestStreamPollersFailed(t *testing.T) {
+	t.Parallel()
+
+	streams := newStreamPollers(context.Background())
+	failing := func(context.Context, func(changes *data.Frame, snapshot *data.Frame)) error {
+		return errors.New("no client")
+	}
+
+	subscription, unsubscribe := streams.subscribe("jobs", failing)
+	defer unsubscribe()
+
+	select {
+	case <-subscription.done:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not done after the poller failed")
+	}
+
+	if err := subscription.err(); err == nil || err.Error() != "no client" {
+		t.Errorf("expected the poller error, got %v", err)
+	}
+
+	// the failed poller is replaced by the next subscriber
+	started := make(chan struct{})
+	next, unsubscribeNext := streams.subscribe("jobs", func(ctx context.Context, _ func(changes *data.Frame, snapshot *data.Frame)) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+	defer unsubscribeNext()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("expected a new poller")
+	}
+
+	select {
+	case <-next.done:
+		t.Error("expected the new poller to keep running")
+	default:
+	}
+}
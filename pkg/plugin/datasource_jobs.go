@@ -7,18 +7,54 @@ import (
 	"fmt"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/databricks/databricks-sdk-go/service/jobs"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/rayalex/databricks/pkg/models"
+)
+
+const (
+	jobRunModeRuns        = "runs"
+	jobRunModeSchedule    = "schedule"
+	jobRunModeSLA         = "sla"
+	jobRunModeFlakiness   = "flakiness"
+	jobRunModeReliability = "reliability"
+
+	jobRunFormatTable      = "table"
+	jobRunFormatTimeSeries = "timeseries"
+	jobRunFormatNumeric    = "numeric"
+	jobRunFormatTimeline   = "timeline"
+
+	// maximum number of jobs whose runs are listed at the same time
+	jobRunsFanOut = 8
 )
 
 type jobRunParams struct {
+	Mode          string `json:"mode,omitempty"`
+	Format        string `json:"format,omitempty"`
 	JobID         string `json:"jobId,omitempty"`
 	ActiveOnly    bool   `json:"activeOnly,omitempty"`
 	CompletedOnly bool   `json:"completedOnly,omitempty"`
 	RunType       string `json:"runType,omitempty"`
+	IncludeCost   bool   `json:"includeCost,omitempty"`
+
+	// subscribe to live updates of the active runs matching the filters
+	Stream bool `json:"stream,omitempty"`
+
+	// duration anomalies, compared to a baseline of the last BaselineRuns successful runs
+	DetectAnomalies bool `json:"detectAnomalies,omitempty"`
+	BaselineRuns    int  `json:"baselineRuns,omitempty"`
+
+	// schedule mode
+	MissedAfter  string `json:"missedAfter,omitempty"`
+	MaxFireTimes int    `json:"maxFireTimes,omitempty"`
+
+	// sla mode, SLAs declared in the query take precedence over the datasource settings
+	SLAs      []models.JobSLA `json:"slas,omitempty"`
+	SLAMetric string          `json:"slaMetric,omitempty"`
 }
 
 func parseJobRunParams(_ backend.DataQuery, qm queryModel) (jobRunParams, error) {
@@ -29,6 +65,22 @@ func parseJobRunParams(_ backend.DataQuery, qm queryModel) (jobRunParams, error)
 		}
 	}
 
+	switch params.Mode {
+	case "":
+		params.Mode = jobRunModeRuns
+	case jobRunModeRuns, jobRunModeSchedule, jobRunModeSLA, jobRunModeFlakiness, jobRunModeReliability:
+	default:
+		return params, fmt.Errorf("unknown mode: %s", params.Mode)
+	}
+
+	switch params.Format {
+	case "":
+		params.Format = jobRunFormatTable
+	case jobRunFormatTable, jobRunFormatTimeSeries, jobRunFormatNumeric, jobRunFormatTimeline:
+	default:
+		return params, fmt.Errorf("unknown format: %s", params.Format)
+	}
+
 	return params, nil
 }
 
@@ -63,18 +115,20 @@ func buildListRunsRequest(params jobRunParams, query backend.DataQuery) (jobs.Li
 	return req, nil
 }
 
-func buildJobRunFrame(runs []jobs.BaseRun) *data.Frame {
+func buildJobRunFrame(runs []jobs.BaseRun, host string) *data.Frame {
 	frame := data.NewFrame("Databricks Job Runs",
-		data.NewField("Start Time", nil, []time.Time{}),
-		data.NewField("End Time", nil, []time.Time{}),
-		data.NewField("Job ID", nil, []string{}),
-		data.NewField("Run ID", nil, []string{}),
+		data.NewField("Start Time", nil, []*time.Time{}),
+		data.NewField("End Time", nil, []*time.Time{}),
+		withLinks(data.NewField("Job ID", nil, []int64{}), jobLink(host)),
+		withLinks(data.NewField("Run ID", nil, []int64{}), jobRunLink(host)),
 		data.NewField("Run Name", nil, []string{}),
 		data.NewField("Description", nil, []string{}),
 		data.NewField("Attempt Number", nil, []int32{}),
 		data.NewField("Status", nil, []string{}),
-		data.NewField("Queue Duration (milliseconds)", nil, []int64{}),
-		data.NewField("Run Duration (milliseconds)", nil, []int64{}),
+		data.NewField("Result State", nil, []*string{}),
+		data.NewField("Termination Code", nil, []*string{}),
+		data.NewField("Queue Duration (milliseconds)", nil, []*int64{}),
+		data.NewField("Run Duration (milliseconds)", nil, []*int64{}),
 		data.NewField("Run URL", nil, []string{}),
 	)
 
@@ -84,17 +138,29 @@ func buildJobRunFrame(runs []jobs.BaseRun) *data.Frame {
 	})
 
 	for _, run := range runs {
+		// durations stay null until they are known
+		var queueDuration, runDuration *int64
+		if !runActive(run) {
+			queueDuration = optionalInt64(run.QueueDuration)
+			runDuration = optionalInt64(run.RunDuration)
+		} else if run.QueueDuration != 0 {
+			// the run has left the queue
+			queueDuration = optionalInt64(run.QueueDuration)
+		}
+
 		frame.AppendRow(
-			time.UnixMilli(run.StartTime),
-			time.UnixMilli(run.EndTime),
-			fmt.Sprintf("%d", run.JobId),
-			fmt.Sprintf("%d", run.RunId),
+			optionalUnixMilli(run.StartTime),
+			optionalUnixMilli(run.EndTime),
+			run.JobId,
+			run.RunId,
 			run.RunName,
 			run.Description,
 			int32(run.AttemptNumber),
-			string(run.Status.State),
-			run.QueueDuration,
-			run.RunDuration,
+			runState(run.Status, run.State),
+			optionalString(runResultState(run)),
+			optionalString(runTerminationCode(run)),
+			queueDuration,
+			runDuration,
 			run.RunPageUrl,
 		)
 	}
@@ -102,12 +168,55 @@ func buildJobRunFrame(runs []jobs.BaseRun) *data.Frame {
 	return frame
 }
 
+// buildJobRunTimelineFrames returns the state timeline of every job: the result state of each
+// run (or its lifecycle state while it runs), idle in between. The frames are named by job id,
+// as run names can differ from run to run.
+func buildJobRunTimelineFrames(runs []jobs.BaseRun, from time.Time) []*data.Frame {
+	spans := map[int64][]timelineSpan{}
+	for _, run := range runs {
+		state := runResultState(run)
+		if state == "" {
+			state = runState(run.Status, run.State)
+		}
+
+		spans[run.JobId] = append(spans[run.JobId], timelineSpan{
+			Start: time.UnixMilli(run.StartTime),
+			End:   optionalUnixMilli(run.EndTime),
+			State: state,
+		})
+	}
+
+	frames := []*data.Frame{}
+	for jobId, jobSpans := range spans {
+		id := strconv.FormatInt(jobId, 10)
+		frames = append(frames, buildTimelineFrame("Job "+id, data.Labels{"job_id": id}, jobSpans, from))
+	}
+
+	return sortTimelineFrames(frames)
+}
+
+// describeListRuns formats a list runs request for the query inspector.
+func describeListRuns(request jobs.ListRunsRequest) string {
+	return describeRequest("GET", "/api/2.2/jobs/runs/list", request)
+}
+
 func (d *Datasource) queryJobRuns(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
 	params, err := parseJobRunParams(query, qm)
 	if err != nil {
 		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
 	}
 
+	switch params.Mode {
+	case jobRunModeSchedule:
+		return d.queryJobSchedule(ctx, pCtx, query, qm, params)
+	case jobRunModeSLA:
+		return d.queryJobSLA(ctx, pCtx, qm, params)
+	case jobRunModeFlakiness:
+		return d.queryJobFlakiness(ctx, pCtx, query, qm, params)
+	case jobRunModeReliability:
+		return d.queryJobReliability(ctx, pCtx, query, qm, params)
+	}
+
 	w, err := d.getDatabricksClient(ctx, pCtx)
 	if err != nil {
 		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
@@ -126,12 +235,173 @@ func (d *Datasource) queryJobRuns(ctx context.Context, pCtx backend.PluginContex
 	}
 
 	var response backend.DataResponse
-	frame := buildJobRunFrame(jobRuns)
-	response.Frames = append(response.Frames, frame)
+	if params.Format == jobRunFormatTimeline {
+		response.Frames = withExecutedQuery(buildJobRunTimelineFrames(jobRuns, query.TimeRange.From), describeListRuns(request))
+		return response
+	}
+
+	frame := buildJobRunFrame(jobRuns, w.Config.Host)
+
+	if params.Stream {
+		frame.SetMeta(&data.FrameMeta{Channel: d.streamChannel(jobRunStreamPath(params))})
+	}
+
+	if params.IncludeCost {
+		var costs map[int64]jobRunCost
+
+		// cost columns stay null when there is no warehouse to look them up with
+		if warehouseId, err := d.getWarehouseId(); err == nil {
+			// in account mode, the usage of this workspace is read through the warehouse's one
+			var workspaceId string
+			if entry, ok := workspaceFromContext(ctx); ok {
+				workspaceId = strconv.FormatInt(entry.workspace.WorkspaceId, 10)
+			}
+
+			warehouse, err := d.getWarehouseClient(ctx, pCtx)
+			if err == nil {
+				costs, err = fetchJobRunCosts(ctx, warehouse, warehouseId, jobRuns, workspaceId)
+			}
+
+			if err != nil {
+				frame.AppendNotices(data.Notice{
+					Severity: data.NoticeSeverityWarning,
+					Text:     fmt.Sprintf("failed to look up run costs: %v", err),
+				})
+			}
+		}
+
+		if err := addJobRunCostFields(frame, costs); err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to add run costs: %v", err))
+		}
+	}
+
+	if params.DetectAnomalies || params.Format == jobRunFormatNumeric {
+		baselineRuns := params.BaselineRuns
+		if baselineRuns <= 0 {
+			baselineRuns = defaultBaselineRuns
+		}
+
+		history, err := d.runHistoryOf(ctx).fetchRunHistory(ctx, jobsService, jobRuns, query.TimeRange.From, baselineRuns)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch run history: %v", err))
+		}
+
+		anomalies := computeRunAnomalies(jobRuns, history, baselineRuns)
+		if params.Format == jobRunFormatNumeric {
+			response.Frames = withExecutedQuery(buildJobRunAnomalyFrames(jobRuns, anomalies), describeListRuns(request))
+			return response
+		}
+
+		if err := addJobRunAnomalyFields(frame, anomalies); err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to add run anomalies: %v", err))
+		}
+	}
+
+	response.Frames = withExecutedQuery([]*data.Frame{frame}, describeListRuns(request))
 	return response
 }
 
+// frameRunIds returns the run ids of the rows of a job run frame, so columns can be added to
+// it after it was built.
+func frameRunIds(frame *data.Frame) ([]int64, error) {
+	field, _ := frame.FieldByName("Run ID")
+	if field == nil {
+		return nil, fmt.Errorf("frame has no run id field")
+	}
+
+	runIds := make([]int64, field.Len())
+	for i := range field.Len() {
+		runId, ok := field.At(i).(int64)
+		if !ok {
+			return nil, fmt.Errorf("invalid run id: %v", field.At(i))
+		}
+
+		runIds[i] = runId
+	}
+
+	return runIds, nil
+}
+
+// runState returns the lifecycle state of a run, from its status, or from the deprecated
+// state when the status is missing.
+func runState(status *jobs.RunStatus, state *jobs.RunState) string {
+	switch {
+	case status != nil:
+		return string(status.State)
+	case state != nil:
+		return string(state.LifeCycleState)
+	default:
+		return ""
+	}
+}
+
+// runTerminationCode returns the termination code of a finished run, empty while it is running.
+func runTerminationCode(run jobs.BaseRun) string {
+	if run.Status == nil || run.Status.TerminationDetails == nil {
+		return ""
+	}
+
+	return string(run.Status.TerminationDetails.Code)
+}
+
+// runResultState returns the result state of a finished run, empty while it is running.
+func runResultState(run jobs.BaseRun) string {
+	if run.State == nil {
+		return ""
+	}
+
+	return string(run.State.ResultState)
+}
+
+// runSucceeded reports whether a run finished successfully.
+func runSucceeded(run jobs.BaseRun) bool {
+	if run.State != nil && run.State.ResultState != "" {
+		return run.State.ResultState == jobs.RunResultStateSuccess
+	}
+
+	return run.Status != nil && run.Status.TerminationDetails != nil &&
+		run.Status.TerminationDetails.Code == jobs.TerminationCodeCodeSuccess
+}
+
+// runActive reports whether a run hasn't finished yet.
+func runActive(run jobs.BaseRun) bool {
+	return run.EndTime == 0
+}
+
 func fetchJobRuns(ctx context.Context, client DatabricksJobsService, request jobs.ListRunsRequest, maxItems int) ([]jobs.BaseRun, error) {
 	iter := client.ListRuns(ctx, request)
 	return fetchWithLimit(ctx, iter, maxItems)
 }
+
+// fetchRunsPerJob lists up to maxItems runs of each job with the given request, so a busy job
+// doesn't use up the limit of the others. The jobs are listed concurrently, the runs are
+// returned in the order of jobIds.
+func fetchRunsPerJob(ctx context.Context, client DatabricksJobsService, request jobs.ListRunsRequest, jobIds []int64, maxItems int) ([][]jobs.BaseRun, error) {
+	runs := make([][]jobs.BaseRun, len(jobIds))
+	errs := make([]error, len(jobIds))
+	semaphore := make(chan struct{}, jobRunsFanOut)
+
+	var wg sync.WaitGroup
+	for i, jobId := range jobIds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			jobRequest := request
+			jobRequest.JobId = jobId
+			runs[i], errs[i] = fetchJobRuns(ctx, client, jobRequest, maxItems)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", jobIds[i], err)
+		}
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,377 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/ml"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	mlflowHistoryFormatTimeSeries = "timeseries"
+	mlflowHistoryFormatTable      = "table"
+
+	// maximum number of metric histories fetched at the same time
+	mlflowHistoryFanOut = 8
+)
+
+type mlflowRunParams struct {
+	ExperimentIDs []string `json:"experimentIds"`
+	Filter        string   `json:"filter,omitempty"`
+	OrderBy       []string `json:"orderBy,omitempty"`
+	Metrics       []string `json:"metrics,omitempty"`
+
+	// metric history only
+	Format string `json:"format,omitempty"`
+}
+
+func parseMlflowRunParams(_ backend.DataQuery, qm queryModel) (mlflowRunParams, error) {
+	var params mlflowRunParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	if len(params.ExperimentIDs) == 0 {
+		return params, fmt.Errorf("at least one experiment id is required")
+	}
+
+	switch params.Format {
+	case "":
+		params.Format = mlflowHistoryFormatTimeSeries
+	case mlflowHistoryFormatTimeSeries, mlflowHistoryFormatTable:
+	default:
+		return params, fmt.Errorf("unknown format: %s", params.Format)
+	}
+
+	return params, nil
+}
+
+// buildSearchRunsRequest combines the MLflow filter expression with the dashboard time
+// range, matched against the run start time.
+func buildSearchRunsRequest(params mlflowRunParams, query backend.DataQuery) ml.SearchRuns {
+	req := ml.SearchRuns{
+		ExperimentIds: params.ExperimentIDs,
+		MaxResults:    1000, // 1000 is the max page size for this API
+		OrderBy:       params.OrderBy,
+		RunViewType:   ml.ViewTypeActiveOnly,
+	}
+
+	filters := []string{}
+	if params.Filter != "" {
+		filters = append(filters, params.Filter)
+	}
+
+	// apply time range filter, if set
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		filters = append(filters,
+			fmt.Sprintf("attributes.start_time >= %d", query.TimeRange.From.UnixMilli()),
+			fmt.Sprintf("attributes.start_time <= %d", query.TimeRange.To.UnixMilli()),
+		)
+	}
+
+	req.Filter = strings.Join(filters, " AND ")
+	return req
+}
+
+// mlflowRunName returns the run name, falling back to the run id for unnamed runs.
+func mlflowRunName(run ml.Run) string {
+	if run.Info == nil {
+		return ""
+	}
+
+	if run.Info.RunName != "" {
+		return run.Info.RunName
+	}
+
+	return run.Info.RunId
+}
+
+// buildMlflowRunsFrame returns one row per run, with a column for every param, tag and
+// latest metric value found across the runs. Internal mlflow.* tags are left out.
+func buildMlflowRunsFrame(runs []ml.Run, metrics []string, host string) *data.Frame {
+	frame := data.NewFrame("Databricks MLflow Runs",
+		data.NewField("Start Time", nil, []*time.Time{}),
+		data.NewField("End Time", nil, []*time.Time{}),
+		withLinks(data.NewField("Experiment ID", nil, []string{}), experimentLink(host)),
+		withLinks(data.NewField("Run ID", nil, []string{}), mlflowRunLink(host)),
+		data.NewField("Run Name", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		data.NewField("User", nil, []string{}),
+	)
+
+	params := map[string]bool{}
+	tags := map[string]bool{}
+	metricKeys := map[string]bool{}
+	for _, run := range runs {
+		if run.Data == nil {
+			continue
+		}
+
+		for _, param := range run.Data.Params {
+			params[param.Key] = true
+		}
+
+		for _, tag := range run.Data.Tags {
+			if !strings.HasPrefix(tag.Key, "mlflow.") {
+				tags[tag.Key] = true
+			}
+		}
+
+		for _, metric := range run.Data.Metrics {
+			if len(metrics) == 0 || slices.Contains(metrics, metric.Key) {
+				metricKeys[metric.Key] = true
+			}
+		}
+	}
+
+	paramColumns := slices.Sorted(maps.Keys(params))
+	tagColumns := slices.Sorted(maps.Keys(tags))
+	metricColumns := slices.Sorted(maps.Keys(metricKeys))
+
+	for _, key := range paramColumns {
+		frame.Fields = append(frame.Fields, data.NewField("param."+key, nil, []*string{}))
+	}
+
+	for _, key := range tagColumns {
+		frame.Fields = append(frame.Fields, data.NewField("tag."+key, nil, []*string{}))
+	}
+
+	for _, key := range metricColumns {
+		frame.Fields = append(frame.Fields, data.NewField("metric."+key, nil, []*float64{}))
+	}
+
+	// sort results ascending by StartTime
+	slices.SortFunc(runs, func(i, j ml.Run) int {
+		var a, b int64
+		if i.Info != nil {
+			a = i.Info.StartTime
+		}
+		if j.Info != nil {
+			b = j.Info.StartTime
+		}
+
+		return cmp.Compare(a, b)
+	})
+
+	for _, run := range runs {
+		info := run.Info
+		if info == nil {
+			info = &ml.RunInfo{}
+		}
+
+		runData := run.Data
+		if runData == nil {
+			runData = &ml.RunData{}
+		}
+
+		row := []any{
+			optionalUnixMilli(info.StartTime),
+			optionalUnixMilli(info.EndTime),
+			info.ExperimentId,
+			info.RunId,
+			mlflowRunName(run),
+			string(info.Status),
+			info.UserId,
+		}
+
+		runParams := map[string]string{}
+		for _, param := range runData.Params {
+			runParams[param.Key] = param.Value
+		}
+
+		runTags := map[string]string{}
+		for _, tag := range runData.Tags {
+			runTags[tag.Key] = tag.Value
+		}
+
+		runMetrics := map[string]float64{}
+		for _, metric := range runData.Metrics {
+			runMetrics[metric.Key] = metric.Value
+		}
+
+		for _, key := range paramColumns {
+			var value *string
+			if v, ok := runParams[key]; ok {
+				value = &v
+			}
+
+			row = append(row, value)
+		}
+
+		for _, key := range tagColumns {
+			var value *string
+			if v, ok := runTags[key]; ok {
+				value = &v
+			}
+
+			row = append(row, value)
+		}
+
+		for _, key := range metricColumns {
+			var value *float64
+			if v, ok := runMetrics[key]; ok {
+				value = &v
+			}
+
+			row = append(row, value)
+		}
+
+		frame.AppendRow(row...)
+	}
+
+	return frame
+}
+
+// buildMlflowMetricHistoryFrame returns the history of one metric of one run, labelled by
+// run name. The table format includes the step, so it can be used as the x axis instead of
+// the time; it is left out of time series, where it would be plotted as another series.
+func buildMlflowMetricHistoryFrame(run ml.Run, key string, history []ml.Metric, format string) *data.Frame {
+	labels := data.Labels{
+		"run":    mlflowRunName(run),
+		"run_id": run.Info.RunId,
+		"metric": key,
+	}
+
+	// sort results ascending by Step, then Timestamp
+	slices.SortFunc(history, func(i, j ml.Metric) int {
+		return cmp.Or(cmp.Compare(i.Step, j.Step), cmp.Compare(i.Timestamp, j.Timestamp))
+	})
+
+	times := make([]time.Time, len(history))
+	steps := make([]int64, len(history))
+	values := make([]float64, len(history))
+	for i, metric := range history {
+		times[i] = time.UnixMilli(metric.Timestamp)
+		steps[i] = metric.Step
+		values[i] = metric.Value
+	}
+
+	if format == mlflowHistoryFormatTable {
+		return data.NewFrame(key,
+			data.NewField("Time", nil, times),
+			data.NewField("Step", nil, steps),
+			data.NewField(key, labels, values),
+		)
+	}
+
+	return data.NewFrame(key,
+		data.NewField("Time", nil, times),
+		data.NewField(key, labels, values),
+	)
+}
+
+func (d *Datasource) queryMlflowRuns(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseMlflowRunParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request := buildSearchRunsRequest(params, query)
+	runs, err := fetchWithLimit(ctx, w.Experiments.SearchRuns(ctx, request), qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to search runs: %v", err))
+	}
+
+	frame := buildMlflowRunsFrame(runs, params.Metrics, w.Config.Host)
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeRequest("POST", "/api/2.0/mlflow/runs/search", request)),
+	}
+}
+
+func (d *Datasource) queryMlflowMetricHistory(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseMlflowRunParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	if len(params.Metrics) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "at least one metric is required")
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request := buildSearchRunsRequest(params, query)
+	runs, err := fetchWithLimit(ctx, w.Experiments.SearchRuns(ctx, request), qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to search runs: %v", err))
+	}
+
+	type historyKey struct {
+		run ml.Run
+		key string
+	}
+
+	keys := []historyKey{}
+	executed := []string{describeRequest("POST", "/api/2.0/mlflow/runs/search", request)}
+	for _, run := range runs {
+		if run.Info == nil {
+			continue
+		}
+
+		for _, key := range params.Metrics {
+			keys = append(keys, historyKey{run: run, key: key})
+			executed = append(executed, describeRequest("GET", "/api/2.0/mlflow/metrics/get-history", ml.GetHistoryRequest{
+				RunId:     run.Info.RunId,
+				MetricKey: key,
+			}))
+		}
+	}
+
+	// one request per run and metric, run concurrently and returned in the order of the runs
+	histories := make([][]ml.Metric, len(keys))
+	errs := make([]error, len(keys))
+	semaphore := make(chan struct{}, mlflowHistoryFanOut)
+
+	var wg sync.WaitGroup
+	for i, k := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			histories[i], errs[i] = w.Experiments.GetHistoryAll(ctx, ml.GetHistoryRequest{
+				RunId:     k.run.Info.RunId,
+				MetricKey: k.key,
+			})
+		}()
+	}
+	wg.Wait()
+
+	frames := []*data.Frame{}
+	for i, k := range keys {
+		if errs[i] != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get history of %s for run %s: %v", k.key, k.run.Info.RunId, errs[i]))
+		}
+
+		if len(histories[i]) == 0 {
+			continue
+		}
+
+		frames = append(frames, buildMlflowMetricHistoryFrame(k.run, k.key, histories[i], params.Format))
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames, executed...),
+	}
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/ml"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestBuildSearchRunsRequest(t *testing.T) {
+	t.Parallel()
+
+	from := time.UnixMilli(1000)
+	to := time.UnixMilli(2000)
+	query := backend.DataQuery{TimeRange: backend.TimeRange{From: from, To: to}}
+
+	req := buildSearchRunsRequest(mlflowRunParams{ExperimentIDs: []string{"1"}, Filter: "metrics.rmse < 1"}, query)
+	expected := "metrics.rmse < 1 AND attributes.start_time >= 1000 AND attributes.start_time <= 2000"
+	if req.Filter != expected {
+		t.Errorf("unexpected filter: %s", req.Filter)
+	}
+
+	req = buildSearchRunsRequest(mlflowRunParams{ExperimentIDs: []string{"1"}}, backend.DataQuery{})
+	if req.Filter != "" {
+		t.Errorf("expected empty filter, got %s", req.Filter)
+	}
+}
+
+func TestBuildMlflowRunsFrame(t *testing.T) {
+	t.Parallel()
+
+	runs := []ml.Run{
+		{
+			Info: &ml.RunInfo{RunId: "b", StartTime: 2000, Status: ml.RunInfoStatusRunning},
+			Data: &ml.RunData{
+				Metrics: []ml.Metric{{Key: "loss", Value: 0.5}},
+				Tags:    []ml.RunTag{{Key: "mlflow.user", Value: "me"}, {Key: "team", Value: "ml"}},
+			},
+		},
+		{
+			Info: &ml.RunInfo{RunId: "a", RunName: "baseline", StartTime: 1000, EndTime: 1500, Status: ml.RunInfoStatusFinished},
+			Data: &ml.RunData{
+				Metrics: []ml.Metric{{Key: "loss", Value: 0.7}, {Key: "rmse", Value: 2}},
+				Params:  []ml.Param{{Key: "lr", Value: "0.01"}},
+			},
+		},
+	}
+
+	frame := buildMlflowRunsFrame(runs, nil, testHost)
+	if frame.Rows() != 2 {
+		t.Fatalf("expected 2 rows, got %d", frame.Rows())
+	}
+
+	for _, name := range []string{"param.lr", "tag.team", "metric.loss", "metric.rmse"} {
+		if _, idx := frame.FieldByName(name); idx == -1 {
+			t.Errorf("expected %s column", name)
+		}
+	}
+
+	if _, idx := frame.FieldByName("tag.mlflow.user"); idx != -1 {
+		t.Error("expected internal tags to be skipped")
+	}
+
+	runName, _ := frame.FieldByName("Run Name")
+	if runName.At(0) != "baseline" || runName.At(1) != "b" {
+		t.Errorf("unexpected run names: %v, %v", runName.At(0), runName.At(1))
+	}
+
+	rmse, _ := frame.FieldByName("metric.rmse")
+	if *rmse.At(0).(*float64) != 2 || rmse.At(1).(*float64) != nil {
+		t.Error("expected rmse only for the first run")
+	}
+
+	frame = buildMlflowRunsFrame(runs, []string{"loss"}, testHost)
+	if _, idx := frame.FieldByName("metric.rmse"); idx != -1 {
+		t.Error("expected rmse to be filtered out")
+	}
+}
+
+func TestBuildMlflowMetricHistoryFrame(t *testing.T) {
+	t.Parallel()
+
+	run := ml.Run{Info: &ml.RunInfo{RunId: "a", RunName: "baseline"}}
+	history := []ml.Metric{
+		{Key: "loss", Step: 2, Timestamp: 3000, Value: 0.4},
+		{Key: "loss", Step: 1, Timestamp: 2000, Value: 0.8},
+	}
+
+	t.Run("should return time and value as time series", func(t *testing.T) {
+		frame := buildMlflowMetricHistoryFrame(run, "loss", slices.Clone(history), mlflowHistoryFormatTimeSeries)
+		if frame.Rows() != 2 || len(frame.Fields) != 2 {
+			t.Fatalf("expected 2 rows and fields, got %d rows and %d fields", frame.Rows(), len(frame.Fields))
+		}
+
+		if frame.Fields[1].At(0).(float64) != 0.8 {
+			t.Error("expected history sorted by step")
+		}
+
+		if frame.Fields[1].Labels["run"] != "baseline" || frame.Fields[1].Labels["metric"] != "loss" {
+			t.Errorf("unexpected labels: %v", frame.Fields[1].Labels)
+		}
+	})
+
+	t.Run("should include the step in table format", func(t *testing.T) {
+		frame := buildMlflowMetricHistoryFrame(run, "loss", slices.Clone(history), mlflowHistoryFormatTable)
+		if frame.Fields[1].Name != "Step" || frame.Fields[1].At(0).(int64) != 1 || frame.Fields[2].At(0).(float64) != 0.8 {
+			t.Error("expected history sorted by step")
+		}
+	})
+}
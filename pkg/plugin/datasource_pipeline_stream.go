@@ -0,0 +1,306 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/client"
+	"github.com/databricks/databricks-sdk-go/service/pipelines"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	pipelineStreamPrefix = "pipelines"
+
+	// the event log is tailed while an update runs, otherwise only new updates are polled for
+	pipelineStreamActiveInterval = 5 * time.Second
+	pipelineStreamIdleInterval   = time.Minute
+
+	// maximum number of event pages read per poll, the rest is read on the next poll
+	pipelineStreamMaxPages = 10
+
+	pipelineEventFlowProgress   = "flow_progress"
+	pipelineEventUpdateProgress = "update_progress"
+
+	// format of the event timestamps, also used in the event log filter
+	pipelineEventTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// pipelineEvent is an event log entry. The SDK's PipelineEvent leaves out the details, which
+// hold the flow progress, so the event log is read into this type instead.
+type pipelineEvent struct {
+	Id        string               `json:"id"`
+	EventType string               `json:"event_type"`
+	Message   string               `json:"message,omitempty"`
+	Timestamp string               `json:"timestamp"`
+	Origin    *pipelines.Origin    `json:"origin,omitempty"`
+	Details   pipelineEventDetails `json:"details"`
+}
+
+type pipelineEventDetails struct {
+	FlowProgress   *flowProgressDetails   `json:"flow_progress,omitempty"`
+	UpdateProgress *updateProgressDetails `json:"update_progress,omitempty"`
+}
+
+type flowProgressDetails struct {
+	Status  string               `json:"status"`
+	Metrics *flowProgressMetrics `json:"metrics,omitempty"`
+}
+
+type flowProgressMetrics struct {
+	NumOutputRows *int64 `json:"num_output_rows,omitempty"`
+}
+
+type updateProgressDetails struct {
+	State string `json:"state"`
+}
+
+type pipelineEventsResponse struct {
+	Events        []pipelineEvent `json:"events"`
+	NextPageToken string          `json:"next_page_token,omitempty"`
+}
+
+// pipelineProgress is the progress of a flow, or of the update itself when FlowName is empty.
+type pipelineProgress struct {
+	Time          time.Time
+	UpdateID      string
+	FlowName      string
+	Status        string
+	RowsProcessed *int64
+	Message       string
+}
+
+func parsePipelineStreamPath(path string) (string, error) {
+	prefix, pipelineId, ok := strings.Cut(path, "/")
+	if prefix != pipelineStreamPrefix || !ok || pipelineId == "" || strings.Contains(pipelineId, "/") {
+		return "", fmt.Errorf("invalid stream path: %s", path)
+	}
+
+	return pipelineId, nil
+}
+
+func pipelineStreamPath(pipelineId string) string {
+	return pipelineStreamPrefix + "/" + pipelineId
+}
+
+// pipelineUpdateFinished reports whether an update reached a terminal state.
+func pipelineUpdateFinished(state pipelines.UpdateInfoState) bool {
+	switch state {
+	case pipelines.UpdateInfoStateCompleted, pipelines.UpdateInfoStateFailed, pipelines.UpdateInfoStateCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// listPipelineEventsSince returns the events logged since the given timestamp, oldest first,
+// reading at most maxPages pages.
+func listPipelineEventsSince(ctx context.Context, c *client.DatabricksClient, pipelineId string, since string, maxPages int) ([]pipelineEvent, error) {
+	path := fmt.Sprintf("/api/2.0/pipelines/%v/events", pipelineId)
+	headers := map[string]string{"Accept": "application/json"}
+
+	request := pipelines.ListPipelineEventsRequest{
+		Filter:     fmt.Sprintf("timestamp >= '%s'", since),
+		MaxResults: 250,
+		OrderBy:    []string{"timestamp asc"},
+	}
+
+	events := []pipelineEvent{}
+	for range maxPages {
+		var response pipelineEventsResponse
+		if err := c.Do(ctx, http.MethodGet, path, headers, nil, request, &response); err != nil {
+			return nil, err
+		}
+
+		events = append(events, response.Events...)
+		if response.NextPageToken == "" {
+			break
+		}
+
+		// the page token can't be combined with the other fields
+		request = pipelines.ListPipelineEventsRequest{MaxResults: request.MaxResults, PageToken: response.NextPageToken}
+	}
+
+	return events, nil
+}
+
+// pipelineProgressOf returns the progress events of an update that weren't seen before, and
+// marks them as seen. Events are read from an inclusive timestamp, so they can repeat.
+func pipelineProgressOf(events []pipelineEvent, updateId string, seen map[string]bool) []pipelineProgress {
+	progress := []pipelineProgress{}
+	for _, event := range events {
+		if seen[event.Id] || event.Origin == nil || event.Origin.UpdateId != updateId {
+			continue
+		}
+		seen[event.Id] = true
+
+		timestamp, err := time.Parse(time.RFC3339, event.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		p := pipelineProgress{Time: timestamp, UpdateID: updateId, Message: event.Message}
+		switch {
+		case event.EventType == pipelineEventFlowProgress && event.Details.FlowProgress != nil:
+			p.FlowName = event.Origin.FlowName
+			p.Status = event.Details.FlowProgress.Status
+			if metrics := event.Details.FlowProgress.Metrics; metrics != nil {
+				p.RowsProcessed = metrics.NumOutputRows
+			}
+		case event.EventType == pipelineEventUpdateProgress && event.Details.UpdateProgress != nil:
+			p.Status = event.Details.UpdateProgress.State
+		default:
+			continue
+		}
+
+		progress = append(progress, p)
+	}
+
+	return progress
+}
+
+func buildPipelineProgressFrame(progress []pipelineProgress, host string, pipelineId string) *data.Frame {
+	frame := data.NewFrame("Databricks Pipeline Progress",
+		data.NewField("Time", nil, []time.Time{}),
+		withLinks(data.NewField("Update ID", nil, []string{}), pipelineUpdateLink(host, pipelineId)),
+		data.NewField("Flow Name", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		data.NewField("Rows Processed", nil, []*int64{}),
+		data.NewField("Message", nil, []string{}),
+	)
+
+	for _, p := range progress {
+		frame.AppendRow(
+			p.Time,
+			p.UpdateID,
+			p.FlowName,
+			p.Status,
+			p.RowsProcessed,
+			p.Message,
+		)
+	}
+
+	return frame
+}
+
+// fetchLatestPipelineUpdate returns the most recent update of a pipeline, or nil if it has
+// never run.
+func fetchLatestPipelineUpdate(ctx context.Context, w *databricks.WorkspaceClient, pipelineId string) (*pipelines.UpdateInfo, error) {
+	request, err := buildPipelineUpdateRequest(pipelineUpdatesParams{PipelineId: pipelineId}, backend.DataQuery{})
+	if err != nil {
+		return nil, err
+	}
+	request.MaxResults = 1
+
+	updates, err := w.Pipelines.ListUpdates(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(updates.Updates) == 0 {
+		return nil, nil
+	}
+
+	return &updates.Updates[0], nil
+}
+
+// pollPipelineUpdates returns the poller of a pipeline stream. While the latest update runs,
+// it tails the event log and publishes the flow progress events as they arrive. Once the
+// update finished, it only checks for a new update at a low frequency. The snapshot holds the
+// latest progress of every flow of the current update.
+func (d *Datasource) pollPipelineUpdates(pCtx backend.PluginContext, pipelineId string) streamPollFunc {
+	return func(ctx context.Context, publish func(changes *data.Frame, snapshot *data.Frame)) error {
+		w, err := d.getDatabricksClient(ctx, pCtx)
+		if err != nil {
+			return fmt.Errorf("failed to get databricks client: %w", err)
+		}
+
+		c, err := client.New(w.Config)
+		if err != nil {
+			return fmt.Errorf("failed to get databricks client: %w", err)
+		}
+
+		var (
+			updateId  string
+			since     string
+			finished  bool
+			published bool
+			seen      map[string]bool
+			latest    map[string]pipelineProgress
+		)
+
+		for {
+			interval := pipelineStreamIdleInterval
+
+			update, err := fetchLatestPipelineUpdate(ctx, w, pipelineId)
+			if err != nil && !published {
+				return fmt.Errorf("failed to poll pipeline updates: %w", err)
+			} else if err != nil {
+				log.DefaultLogger.Warn("failed to poll pipeline updates", "error", err)
+			} else if update != nil {
+				if update.UpdateId != updateId {
+					updateId = update.UpdateId
+					since = time.UnixMilli(update.CreationTime).UTC().Format(pipelineEventTimeFormat)
+					finished = false
+					seen = map[string]bool{}
+					latest = map[string]pipelineProgress{}
+				}
+
+				if !finished {
+					// the update state is read before the events, so the last events of a
+					// finished update are still read once
+					finished = pipelineUpdateFinished(update.State)
+
+					events, err := listPipelineEventsSince(ctx, c, pipelineId, since, pipelineStreamMaxPages)
+					if err != nil {
+						log.DefaultLogger.Warn("failed to read pipeline events", "error", err)
+						finished = false
+					} else {
+						if len(events) > 0 {
+							since = events[len(events)-1].Timestamp
+						}
+
+						progress := pipelineProgressOf(events, updateId, seen)
+						for _, p := range progress {
+							latest[p.FlowName] = p
+						}
+
+						snapshot := buildPipelineProgressFrame(slices.SortedFunc(maps.Values(latest), func(i, j pipelineProgress) int {
+							return cmp.Compare(i.FlowName, j.FlowName)
+						}), w.Config.Host, pipelineId)
+
+						if !published {
+							publish(snapshot, snapshot)
+							published = true
+						} else if len(progress) > 0 {
+							publish(buildPipelineProgressFrame(progress, w.Config.Host, pipelineId), snapshot)
+						}
+					}
+				}
+
+				if !finished {
+					interval = pipelineStreamActiveInterval
+				}
+			} else if !published {
+				snapshot := buildPipelineProgressFrame(nil, w.Config.Host, pipelineId)
+				publish(snapshot, snapshot)
+				published = true
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	}
+}
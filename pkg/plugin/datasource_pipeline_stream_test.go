@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/databricks/databricks-sdk-go/service/pipelines"
+)
+
+func TestParsePipelineStreamPath(t *testing.T) {
+	t.Parallel()
+
+	pipelineId, err := parsePipelineStreamPath("pipelines/0a1b-2c3d")
+	if err != nil || pipelineId != "0a1b-2c3d" {
+		t.Fatalf("expected pipeline 0a1b-2c3d, got %q (%v)", pipelineId, err)
+	}
+
+	for _, path := range []string{"pipelines", "pipelines/", "pipelines/a/b", "jobs/a"} {
+		if _, err := parsePipelineStreamPath(path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
+
+func TestPipelineProgressOf(t *testing.T) {
+	t.Parallel()
+
+	var response pipelineEventsResponse
+	err := json.Unmarshal([]byte(`{"events": [
+		{"id": "1", "event_type": "update_progress", "timestamp": "2025-01-01T10:00:00.000Z",
+		 "origin": {"update_id": "u1"}, "details": {"update_progress": {"state": "RUNNING"}}},
+		{"id": "2", "event_type": "flow_progress", "timestamp": "2025-01-01T10:00:05.000Z",
+		 "origin": {"update_id": "u1", "flow_name": "bronze"},
+		 "details": {"flow_progress": {"status": "RUNNING"}}},
+		{"id": "3", "event_type": "flow_progress", "timestamp": "2025-01-01T10:01:00.000Z",
+		 "origin": {"update_id": "u1", "flow_name": "bronze"},
+		 "details": {"flow_progress": {"status": "COMPLETED", "metrics": {"num_output_rows": 1200}}}},
+		{"id": "4", "event_type": "flow_progress", "timestamp": "2025-01-01T10:01:00.000Z",
+		 "origin": {"update_id": "u0", "flow_name": "bronze"},
+		 "details": {"flow_progress": {"status": "COMPLETED"}}},
+		{"id": "5", "event_type": "cluster_resources", "timestamp": "2025-01-01T10:01:00.000Z",
+		 "origin": {"update_id": "u1"}}
+	]}`), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	progress := pipelineProgressOf(response.Events, "u1", seen)
+	if len(progress) != 3 {
+		t.Fatalf("expected 3 progress events, got %+v", progress)
+	}
+
+	if progress[0].FlowName != "" || progress[0].Status != "RUNNING" {
+		t.Errorf("expected update progress first, got %+v", progress[0])
+	}
+
+	if progress[2].FlowName != "bronze" || progress[2].Status != "COMPLETED" || progress[2].RowsProcessed == nil || *progress[2].RowsProcessed != 1200 {
+		t.Errorf("expected completed bronze flow with 1200 rows, got %+v", progress[2])
+	}
+
+	// events are read again from the last timestamp, and must not repeat
+	if again := pipelineProgressOf(response.Events[2:], "u1", seen); len(again) != 0 {
+		t.Errorf("expected no new progress, got %+v", again)
+	}
+
+	frame := buildPipelineProgressFrame(progress, testHost, "p1")
+	if rows, _ := frame.RowLen(); rows != 3 {
+		t.Errorf("expected 3 rows, got %d", rows)
+	}
+
+	if !pipelineUpdateFinished(pipelines.UpdateInfoStateFailed) || pipelineUpdateFinished(pipelines.UpdateInfoStateRunning) {
+		t.Error("expected only terminal states to be finished")
+	}
+}
@@ -6,17 +6,30 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/databricks/databricks-sdk-go/client"
 	"github.com/databricks/databricks-sdk-go/service/pipelines"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
+const (
+	pipelineUpdatesFormatTable    = "table"
+	pipelineUpdatesFormatTimeline = "timeline"
+
+	// maximum number of event pages read to find when the updates of a timeline ended
+	pipelineTimelineMaxPages = 40
+)
+
 type pipelineParams struct {
 	Filter string `json:"filter,omitempty"`
 }
 
 type pipelineUpdatesParams struct {
 	PipelineId string `json:"pipelineId"`
+	Format     string `json:"format,omitempty"`
+
+	// subscribe to the live progress of the pipeline's running update
+	Stream bool `json:"stream,omitempty"`
 }
 
 func parsePipelineParams(_ backend.DataQuery, qm queryModel) (pipelineParams, error) {
@@ -38,6 +51,18 @@ func parsePipelineUpdateParams(_ backend.DataQuery, qm queryModel) (pipelineUpda
 		}
 	}
 
+	if params.PipelineId == "" {
+		return params, fmt.Errorf("pipeline id is required")
+	}
+
+	switch params.Format {
+	case "":
+		params.Format = pipelineUpdatesFormatTable
+	case pipelineUpdatesFormatTable, pipelineUpdatesFormatTimeline:
+	default:
+		return params, fmt.Errorf("unknown format: %s", params.Format)
+	}
+
 	return params, nil
 }
 
@@ -58,10 +83,10 @@ func buildPipelineUpdateRequest(params pipelineUpdatesParams, query backend.Data
 	return req, nil
 }
 
-func buildPipelinesRunFrame(pipelines []pipelines.PipelineStateInfo) *data.Frame {
+func buildPipelinesRunFrame(pipelines []pipelines.PipelineStateInfo, host string) *data.Frame {
 	frame := data.NewFrame("pipelines")
 	frame.Fields = append(frame.Fields,
-		data.NewField("Pipeline Id", nil, []string{}),
+		withLinks(data.NewField("Pipeline Id", nil, []string{}), pipelineLink(host)),
 		data.NewField("Pipeline Name", nil, []string{}),
 		data.NewField("State", nil, []string{}),
 	)
@@ -77,12 +102,12 @@ func buildPipelinesRunFrame(pipelines []pipelines.PipelineStateInfo) *data.Frame
 	return frame
 }
 
-func buildPipelineUpdatesFrame(updates []pipelines.UpdateInfo) *data.Frame {
+func buildPipelineUpdatesFrame(updates []pipelines.UpdateInfo, host string) *data.Frame {
 	frame := data.NewFrame("pipeline updates")
 	frame.Fields = append(frame.Fields,
 		data.NewField("Creation Time", nil, []time.Time{}),
-		data.NewField("Update Id", nil, []string{}),
-		data.NewField("Pipeline Id", nil, []string{}),
+		withLinks(data.NewField("Update Id", nil, []string{}), pipelineUpdateLink(host, fieldVar("Pipeline Id"))),
+		withLinks(data.NewField("Pipeline Id", nil, []string{}), pipelineLink(host)),
 		data.NewField("Cause", nil, []string{}),
 		data.NewField("State", nil, []string{}),
 	)
@@ -100,6 +125,65 @@ func buildPipelineUpdatesFrame(updates []pipelines.UpdateInfo) *data.Frame {
 	return frame
 }
 
+// pipelineUpdatesInRange returns the updates created within the time range, most recent
+// first, as they are listed.
+func pipelineUpdatesInRange(updates []pipelines.UpdateInfo, timeRange backend.TimeRange) []pipelines.UpdateInfo {
+	inRange := []pipelines.UpdateInfo{}
+	for _, update := range updates {
+		created := time.UnixMilli(update.CreationTime)
+		if !timeRange.From.IsZero() && created.Before(timeRange.From) {
+			continue
+		}
+
+		if !timeRange.To.IsZero() && created.After(timeRange.To) {
+			continue
+		}
+
+		inRange = append(inRange, update)
+	}
+
+	return inRange
+}
+
+// pipelineUpdateSpans returns the timeline spans of the updates, from their creation until
+// the update progress event of their final state. Finished updates without such an event end
+// when they started, and are counted as missing.
+func pipelineUpdateSpans(updates []pipelines.UpdateInfo, events []pipelineEvent) ([]timelineSpan, int) {
+	ends := map[string]time.Time{}
+	for _, event := range events {
+		if event.EventType != pipelineEventUpdateProgress || event.Origin == nil || event.Details.UpdateProgress == nil {
+			continue
+		}
+
+		if !pipelineUpdateFinished(pipelines.UpdateInfoState(event.Details.UpdateProgress.State)) {
+			continue
+		}
+
+		if timestamp, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+			ends[event.Origin.UpdateId] = timestamp
+		}
+	}
+
+	spans := []timelineSpan{}
+	missing := 0
+	for _, update := range updates {
+		span := timelineSpan{Start: time.UnixMilli(update.CreationTime), State: string(update.State)}
+		if pipelineUpdateFinished(update.State) {
+			end, ok := ends[update.UpdateId]
+			if !ok {
+				end = span.Start
+				missing++
+			}
+
+			span.End = &end
+		}
+
+		spans = append(spans, span)
+	}
+
+	return spans, missing
+}
+
 func (d *Datasource) queryPipelines(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
 	params, err := parsePipelineParams(query, qm)
 	if err != nil {
@@ -122,9 +206,9 @@ func (d *Datasource) queryPipelines(ctx context.Context, pCtx backend.PluginCont
 		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list pipelines: %v", err))
 	}
 
-	frame := buildPipelinesRunFrame(pipelines)
+	frame := buildPipelinesRunFrame(pipelines, w.Config.Host)
 	return backend.DataResponse{
-		Frames: []*data.Frame{frame},
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeRequest("GET", "/api/2.0/pipelines", request)),
 	}
 }
 
@@ -144,13 +228,60 @@ func (d *Datasource) queryPipelineUpdates(ctx context.Context, pCtx backend.Plug
 		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build request: %v", err))
 	}
 
-	updates, err := w.Pipelines.ListUpdates(context.Background(), request)
+	updates, err := w.Pipelines.ListUpdates(ctx, request)
 	if err != nil {
 		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list updates: %v", err))
 	}
 
-	frame := buildPipelineUpdatesFrame(updates.Updates)
+	executed := []string{describeRequest("GET", "/api/2.0/pipelines/"+request.PipelineId+"/updates", request)}
+	if params.Format == pipelineUpdatesFormatTimeline {
+		pipeline, err := w.Pipelines.GetByPipelineId(ctx, params.PipelineId)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get pipeline: %v", err))
+		}
+
+		c, err := client.New(w.Config)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+		}
+
+		inRange := pipelineUpdatesInRange(updates.Updates, query.TimeRange)
+
+		// the updates don't have an end time, it is read from the update progress events
+		events := []pipelineEvent{}
+		if len(inRange) > 0 {
+			since := time.UnixMilli(inRange[len(inRange)-1].CreationTime).UTC().Format(pipelineEventTimeFormat)
+			events, err = listPipelineEventsSince(ctx, c, params.PipelineId, since, pipelineTimelineMaxPages)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to read pipeline events: %v", err))
+			}
+
+			executed = append(executed, describeRequest("GET", "/api/2.0/pipelines/"+params.PipelineId+"/events", pipelines.ListPipelineEventsRequest{
+				Filter:  fmt.Sprintf("timestamp >= '%s'", since),
+				OrderBy: []string{"timestamp asc"},
+			}))
+		}
+
+		spans, missing := pipelineUpdateSpans(inRange, events)
+		frame := buildTimelineFrame(pipeline.Name, data.Labels{"pipeline_id": params.PipelineId}, spans, query.TimeRange.From)
+		if missing > 0 {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("end time of %d updates not found in the event log, they are shown as instant", missing),
+			})
+		}
+
+		return backend.DataResponse{
+			Frames: withExecutedQuery([]*data.Frame{frame}, executed...),
+		}
+	}
+
+	frame := buildPipelineUpdatesFrame(updates.Updates, w.Config.Host)
+	if params.Stream {
+		frame.SetMeta(&data.FrameMeta{Channel: d.streamChannel(pipelineStreamPath(params.PipelineId))})
+	}
+
 	return backend.DataResponse{
-		Frames: []*data.Frame{frame},
+		Frames: withExecutedQuery([]*data.Frame{frame}, executed...),
 	}
 }
@@ -0,0 +1,251 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	queryHistoryModeList      = "list"
+	queryHistoryModeAggregate = "aggregate"
+
+	// maxQueryTextLength is the number of characters of the query text returned per row
+	maxQueryTextLength = 1000
+)
+
+type queryHistoryParams struct {
+	Mode         string   `json:"mode,omitempty"`
+	WarehouseIDs []string `json:"warehouseIds,omitempty"`
+	UserIDs      []string `json:"userIds,omitempty"`
+	Statuses     []string `json:"statuses,omitempty"`
+}
+
+func parseQueryHistoryParams(_ backend.DataQuery, qm queryModel) (queryHistoryParams, error) {
+	var params queryHistoryParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	switch params.Mode {
+	case "":
+		params.Mode = queryHistoryModeList
+	case queryHistoryModeList, queryHistoryModeAggregate:
+	default:
+		return params, fmt.Errorf("unknown mode: %s", params.Mode)
+	}
+
+	return params, nil
+}
+
+func buildQueryHistoryRequest(params queryHistoryParams, query backend.DataQuery) (sql.ListQueryHistoryRequest, error) {
+	req := sql.ListQueryHistoryRequest{
+		IncludeMetrics: true,
+		MaxResults:     1000, // 1000 is the max limit for this API - per page
+		FilterBy: &sql.QueryFilter{
+			WarehouseIds: params.WarehouseIDs,
+		},
+	}
+
+	for _, userId := range params.UserIDs {
+		id, err := strconv.ParseInt(userId, 10, 64)
+		if err != nil {
+			return req, err
+		}
+
+		req.FilterBy.UserIds = append(req.FilterBy.UserIds, id)
+	}
+
+	for _, status := range params.Statuses {
+		var s sql.QueryStatus
+		if err := s.Set(strings.ToUpper(status)); err != nil {
+			return req, err
+		}
+
+		req.FilterBy.Statuses = append(req.FilterBy.Statuses, s)
+	}
+
+	// apply time range filter, if set
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		req.FilterBy.QueryStartTimeRange = &sql.TimeRange{
+			StartTimeMs: query.TimeRange.From.UnixMilli(),
+			EndTimeMs:   query.TimeRange.To.UnixMilli(),
+		}
+	}
+
+	return req, nil
+}
+
+func truncateText(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length]) + "…"
+}
+
+func buildQueryHistoryFrame(queries []sql.QueryInfo, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Query History",
+		data.NewField("Start Time", nil, []time.Time{}),
+		withLinks(data.NewField("Query ID", nil, []string{}), queryProfileLink(host)),
+		withLinks(data.NewField("Warehouse ID", nil, []string{}), warehouseLink(host)),
+		data.NewField("Query Text", nil, []string{}),
+		data.NewField("Statement Type", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		data.NewField("User", nil, []string{}),
+		data.NewField("Duration (milliseconds)", nil, []int64{}),
+		data.NewField("Rows Produced", nil, []int64{}),
+		data.NewField("Bytes Read", nil, []*int64{}),
+		data.NewField("Spill To Disk (bytes)", nil, []*int64{}),
+		data.NewField("Error Message", nil, []string{}),
+	)
+
+	// sort results ascending by StartTime
+	slices.SortFunc(queries, func(i, j sql.QueryInfo) int {
+		return cmp.Compare(i.QueryStartTimeMs, j.QueryStartTimeMs)
+	})
+
+	for _, q := range queries {
+		var readBytes, spillBytes *int64
+		if q.Metrics != nil {
+			readBytes = optionalInt64(q.Metrics.ReadBytes)
+			spillBytes = optionalInt64(q.Metrics.SpillToDiskBytes)
+		}
+
+		frame.AppendRow(
+			time.UnixMilli(q.QueryStartTimeMs),
+			q.QueryId,
+			q.WarehouseId,
+			truncateText(q.QueryText, maxQueryTextLength),
+			string(q.StatementType),
+			string(q.Status),
+			q.UserName,
+			q.Duration,
+			q.RowsProduced,
+			readBytes,
+			spillBytes,
+			q.ErrorMessage,
+		)
+	}
+
+	return frame
+}
+
+// buildQueryHistoryAggregateFrames buckets queries by start time and returns the p95 latency
+// and error rate of completed queries per warehouse, one frame per warehouse.
+func buildQueryHistoryAggregateFrames(queries []sql.QueryInfo, times []time.Time) []*data.Frame {
+	type bucket struct {
+		durations []float64
+		failed    int
+	}
+
+	byWarehouse := map[string][]bucket{}
+	for _, q := range queries {
+		switch q.Status {
+		case sql.QueryStatusFinished, sql.QueryStatusFailed, sql.QueryStatusCanceled:
+		default:
+			continue
+		}
+
+		i := bucketIndex(times, time.UnixMilli(q.QueryStartTimeMs))
+		if i < 0 {
+			continue
+		}
+
+		if _, ok := byWarehouse[q.WarehouseId]; !ok {
+			byWarehouse[q.WarehouseId] = make([]bucket, len(times))
+		}
+
+		b := &byWarehouse[q.WarehouseId][i]
+		b.durations = append(b.durations, float64(q.Duration))
+		if q.Status == sql.QueryStatusFailed {
+			b.failed++
+		}
+	}
+
+	warehouseIds := []string{}
+	for id := range byWarehouse {
+		warehouseIds = append(warehouseIds, id)
+	}
+	slices.Sort(warehouseIds)
+
+	frames := []*data.Frame{}
+	for _, id := range warehouseIds {
+		labels := data.Labels{"warehouse_id": id}
+		latency := make([]*float64, len(times))
+		errorRate := make([]*float64, len(times))
+
+		for i, b := range byWarehouse[id] {
+			if len(b.durations) == 0 {
+				continue
+			}
+
+			p95 := percentile(b.durations, 95)
+			rate := float64(b.failed) / float64(len(b.durations))
+			latency[i] = &p95
+			errorRate[i] = &rate
+		}
+
+		frame := data.NewFrame(id,
+			data.NewField("Time", nil, times),
+			data.NewField("P95 Duration (milliseconds)", labels, latency),
+			data.NewField("Error Rate", labels, errorRate),
+		)
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryQueryHistory(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseQueryHistoryParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	request, err := buildQueryHistoryRequest(params, query)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build request: %v", err))
+	}
+
+	queries, err := fetchWithLimit(ctx, listQueryHistory(w, request), qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list query history: %v", err))
+	}
+
+	if params.Mode == queryHistoryModeAggregate {
+		frames := buildQueryHistoryAggregateFrames(queries, sampleTimes(query))
+		if len(queries) == qm.Limit && len(frames) > 0 {
+			frames[0].AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("only the first %d queries of the query history were aggregated, so latency and error rate are incomplete; increase Max Results or narrow the time range", qm.Limit),
+			})
+		}
+
+		return backend.DataResponse{
+			Frames: withExecutedQuery(frames, describeRequest("GET", "/api/2.0/sql/history/queries", request)),
+		}
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{buildQueryHistoryFrame(queries, w.Config.Host)}, describeRequest("GET", "/api/2.0/sql/history/queries", request)),
+	}
+}
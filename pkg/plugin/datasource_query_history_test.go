@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestBuildQueryHistoryRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should set filters", func(t *testing.T) {
+		params := queryHistoryParams{
+			WarehouseIDs: []string{"wh1"},
+			UserIDs:      []string{"42"},
+			Statuses:     []string{"failed"},
+		}
+
+		req, err := buildQueryHistoryRequest(params, backend.DataQuery{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if req.FilterBy.UserIds[0] != 42 || req.FilterBy.Statuses[0] != sql.QueryStatusFailed || req.FilterBy.WarehouseIds[0] != "wh1" {
+			t.Errorf("unexpected filter: %+v", req.FilterBy)
+		}
+	})
+
+	t.Run("should return error when user id is invalid", func(t *testing.T) {
+		_, err := buildQueryHistoryRequest(queryHistoryParams{UserIDs: []string{"me"}}, backend.DataQuery{})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBuildQueryHistoryAggregateFrames(t *testing.T) {
+	t.Parallel()
+
+	base := time.UnixMilli(0).Add(time.Hour)
+	times := []time.Time{base, base.Add(time.Minute)}
+	queries := []sql.QueryInfo{
+		{WarehouseId: "wh1", QueryStartTimeMs: base.UnixMilli(), Duration: 100, Status: sql.QueryStatusFinished},
+		{WarehouseId: "wh1", QueryStartTimeMs: base.UnixMilli() + 10, Duration: 300, Status: sql.QueryStatusFailed},
+		{WarehouseId: "wh1", QueryStartTimeMs: base.UnixMilli() + 20, Duration: 200, Status: sql.QueryStatusRunning},
+	}
+
+	frames := buildQueryHistoryAggregateFrames(queries, times)
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 frame, got %d", len(frames))
+	}
+
+	latency, _ := frames[0].FieldByName("P95 Duration (milliseconds)")
+	if *latency.At(0).(*float64) != 300 {
+		t.Errorf("expected p95 of 300, got %v", *latency.At(0).(*float64))
+	}
+
+	if latency.At(1).(*float64) != nil {
+		t.Error("expected empty bucket to be null")
+	}
+
+	errorRate, _ := frames[0].FieldByName("Error Rate")
+	if *errorRate.At(0).(*float64) != 0.5 {
+		t.Errorf("expected error rate of 0.5, got %v", *errorRate.At(0).(*float64))
+	}
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/serving"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type servingEndpointParams struct {
+	Names []string `json:"names,omitempty"`
+}
+
+func parseServingEndpointParams(_ backend.DataQuery, qm queryModel) (servingEndpointParams, error) {
+	var params servingEndpointParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	return params, nil
+}
+
+// servedEntityTraffic returns the traffic percentage routed to each served entity.
+func servedEntityTraffic(config *serving.EndpointCoreConfigOutput) map[string]int64 {
+	traffic := map[string]int64{}
+	if config == nil || config.TrafficConfig == nil {
+		return traffic
+	}
+
+	for _, route := range config.TrafficConfig.Routes {
+		traffic[route.ServedModelName] = int64(route.TrafficPercentage)
+	}
+
+	return traffic
+}
+
+// buildServingEndpointsFrame returns one row per served entity, so the traffic split and
+// scaling settings of each entity are visible. Endpoints without served entities get a
+// single row.
+func buildServingEndpointsFrame(endpoints []serving.ServingEndpointDetailed, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Serving Endpoints",
+		withLinks(data.NewField("Endpoint Name", nil, []string{}), servingEndpointLink(host)),
+		data.NewField("Endpoint ID", nil, []string{}),
+		data.NewField("Ready", nil, []string{}),
+		data.NewField("Config Update", nil, []string{}),
+		data.NewField("Task", nil, []string{}),
+		data.NewField("Creator", nil, []string{}),
+		data.NewField("Last Updated", nil, []*time.Time{}),
+		data.NewField("Served Entity", nil, []string{}),
+		data.NewField("Entity Name", nil, []string{}),
+		data.NewField("Entity Version", nil, []string{}),
+		data.NewField("Workload Size", nil, []string{}),
+		data.NewField("Scale To Zero", nil, []*bool{}),
+		data.NewField("Traffic (%)", nil, []*int64{}),
+	)
+
+	for _, endpoint := range endpoints {
+		var ready, configUpdate string
+		if endpoint.State != nil {
+			ready = string(endpoint.State.Ready)
+			configUpdate = string(endpoint.State.ConfigUpdate)
+		}
+
+		appendRow := func(entity *serving.ServedEntityOutput, traffic *int64) {
+			var name, entityName, entityVersion, workloadSize string
+			var scaleToZero *bool
+			if entity != nil {
+				name = entity.Name
+				entityName = entity.EntityName
+				entityVersion = entity.EntityVersion
+				workloadSize = entity.WorkloadSize
+				scaleToZero = &entity.ScaleToZeroEnabled
+			}
+
+			frame.AppendRow(
+				endpoint.Name,
+				endpoint.Id,
+				ready,
+				configUpdate,
+				endpoint.Task,
+				endpoint.Creator,
+				optionalUnixMilli(endpoint.LastUpdatedTimestamp),
+				name,
+				entityName,
+				entityVersion,
+				workloadSize,
+				scaleToZero,
+				traffic,
+			)
+		}
+
+		if endpoint.Config == nil || len(endpoint.Config.ServedEntities) == 0 {
+			appendRow(nil, nil)
+			continue
+		}
+
+		traffic := servedEntityTraffic(endpoint.Config)
+		for _, entity := range endpoint.Config.ServedEntities {
+			var percentage *int64
+			if p, ok := traffic[entity.Name]; ok {
+				percentage = optionalInt64(p)
+			}
+
+			appendRow(&entity, percentage)
+		}
+	}
+
+	return frame
+}
+
+func (d *Datasource) queryServingEndpoints(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseServingEndpointParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	endpoints, err := fetchMatching(ctx, w.ServingEndpoints.List(ctx), qm.Limit, func(endpoint serving.ServingEndpoint) bool {
+		return len(params.Names) == 0 || slices.Contains(params.Names, endpoint.Name)
+	})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list serving endpoints: %v", err))
+	}
+
+	executed := []string{describeRequest("GET", "/api/2.0/serving-endpoints", nil)}
+
+	// the list API only returns a summary of the config, without scaling and traffic settings
+	detailed := []serving.ServingEndpointDetailed{}
+	for _, endpoint := range endpoints {
+		details, err := w.ServingEndpoints.GetByName(ctx, endpoint.Name)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get serving endpoint %s: %v", endpoint.Name, err))
+		}
+
+		detailed = append(detailed, *details)
+		executed = append(executed, describeRequest("GET", "/api/2.0/serving-endpoints/"+endpoint.Name, nil))
+	}
+
+	frame := buildServingEndpointsFrame(detailed, w.Config.Host)
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, executed...),
+	}
+}
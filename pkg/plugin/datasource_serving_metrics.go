@@ -0,0 +1,289 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// servingMetricsRetention is how long scraped samples are kept in memory
+	servingMetricsRetention = time.Hour
+
+	// servingMetricsMaxSamples caps the samples kept per series, regardless of scrape frequency
+	servingMetricsMaxSamples = 720
+)
+
+type servingMetricsParams struct {
+	Names   []string `json:"names"`
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+type metricSample struct {
+	Time  time.Time
+	Value float64
+}
+
+type metricSeries struct {
+	Name    string
+	Labels  data.Labels
+	Samples []metricSample
+}
+
+// metricsBuffer keeps a short rolling history of scraped samples, since the metrics export
+// only returns the current values.
+type metricsBuffer struct {
+	mu        sync.Mutex
+	retention time.Duration
+	series    map[string]*metricSeries
+}
+
+func newMetricsBuffer(retention time.Duration) *metricsBuffer {
+	return &metricsBuffer{
+		retention: retention,
+		series:    map[string]*metricSeries{},
+	}
+}
+
+// add records the scraped series, dropping samples older than the retention.
+func (b *metricsBuffer) add(scraped []metricSeries, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range scraped {
+		key := s.Name + s.Labels.String()
+		existing, ok := b.series[key]
+		if !ok {
+			existing = &metricSeries{Name: s.Name, Labels: s.Labels}
+			b.series[key] = existing
+		}
+
+		for _, sample := range s.Samples {
+			last := len(existing.Samples) - 1
+			if last >= 0 && !sample.Time.After(existing.Samples[last].Time) {
+				continue
+			}
+
+			existing.Samples = append(existing.Samples, sample)
+		}
+	}
+
+	cutoff := now.Add(-b.retention)
+	for key, s := range b.series {
+		first := sort.Search(len(s.Samples), func(i int) bool {
+			return !s.Samples[i].Time.Before(cutoff)
+		})
+
+		first = max(first, len(s.Samples)-servingMetricsMaxSamples)
+		s.Samples = slices.Clone(s.Samples[first:])
+		if len(s.Samples) == 0 {
+			delete(b.series, key)
+		}
+	}
+}
+
+// query returns a copy of the matching series with the samples within the given range.
+func (b *metricsBuffer) query(match func(metricSeries) bool, from time.Time, to time.Time) []metricSeries {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := []metricSeries{}
+	for _, s := range b.series {
+		if !match(*s) {
+			continue
+		}
+
+		samples := []metricSample{}
+		for _, sample := range s.Samples {
+			if !sample.Time.Before(from) && !sample.Time.After(to) {
+				samples = append(samples, sample)
+			}
+		}
+
+		if len(samples) > 0 {
+			result = append(result, metricSeries{Name: s.Name, Labels: s.Labels, Samples: samples})
+		}
+	}
+
+	slices.SortFunc(result, func(i, j metricSeries) int {
+		return cmp.Or(
+			cmp.Compare(i.Name, j.Name),
+			cmp.Compare(i.Labels.String(), j.Labels.String()),
+		)
+	})
+
+	return result
+}
+
+func parseServingMetricsParams(_ backend.DataQuery, qm queryModel) (servingMetricsParams, error) {
+	var params servingMetricsParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	if len(params.Names) == 0 {
+		return params, fmt.Errorf("at least one endpoint name is required")
+	}
+
+	return params, nil
+}
+
+// parseMetricsExposition parses the Prometheus text format into one series per metric and
+// label set, labelled with the endpoint (an endpoint label of the exposition is kept as
+// exported_endpoint). Histograms and summaries are flattened into their _bucket, _sum and
+// _count (or quantile) series. Samples without a timestamp get now.
+func parseMetricsExposition(in io.Reader, endpoint string, now time.Time) ([]metricSeries, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(in)
+	if err != nil {
+		return nil, err
+	}
+
+	series := []metricSeries{}
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := data.Labels{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if value, ok := labels["endpoint"]; ok {
+				labels["exported_endpoint"] = value
+			}
+			labels["endpoint"] = endpoint
+
+			timestamp := now
+			if metric.TimestampMs != nil {
+				timestamp = time.UnixMilli(metric.GetTimestampMs())
+			}
+
+			add := func(name string, labels data.Labels, value float64) {
+				if math.IsNaN(value) {
+					return
+				}
+
+				series = append(series, metricSeries{
+					Name:    name,
+					Labels:  labels,
+					Samples: []metricSample{{Time: timestamp, Value: value}},
+				})
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, labels, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, labels, metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, labels, metric.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					add(name+"_bucket", withLabel(labels, "le", formatFloat(bucket.GetUpperBound())), float64(bucket.GetCumulativeCount()))
+				}
+				add(name+"_sum", labels, histogram.GetSampleSum())
+				add(name+"_count", labels, float64(histogram.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					add(name, withLabel(labels, "quantile", formatFloat(quantile.GetQuantile())), quantile.GetValue())
+				}
+				add(name+"_sum", labels, summary.GetSampleSum())
+				add(name+"_count", labels, float64(summary.GetSampleCount()))
+			}
+		}
+	}
+
+	return series, nil
+}
+
+func withLabel(labels data.Labels, name string, value string) data.Labels {
+	copied := labels.Copy()
+	copied[name] = value
+	return copied
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func buildServingMetricsFrames(series []metricSeries) []*data.Frame {
+	frames := []*data.Frame{}
+	for _, s := range series {
+		times := make([]time.Time, len(s.Samples))
+		values := make([]float64, len(s.Samples))
+		for i, sample := range s.Samples {
+			times[i] = sample.Time
+			values[i] = sample.Value
+		}
+
+		frame := data.NewFrame(s.Name,
+			data.NewField("Time", nil, times),
+			data.NewField(s.Name, s.Labels, values),
+		)
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryServingEndpointMetrics(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseServingMetricsParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	now := time.Now()
+	executed := []string{}
+	for _, name := range params.Names {
+		executed = append(executed, describeRequest("GET", "/api/2.0/serving-endpoints/"+name+"/metrics", nil))
+
+		metrics, err := w.ServingEndpoints.ExportMetricsByName(ctx, name)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to export metrics of %s: %v", name, err))
+		}
+
+		series, err := parseMetricsExposition(metrics.Contents, name, now)
+		metrics.Contents.Close()
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to parse metrics of %s: %v", name, err))
+		}
+
+		d.metricsBufferOf(ctx).add(series, now)
+	}
+
+	from, to := query.TimeRange.From, query.TimeRange.To
+	if from.IsZero() || to.IsZero() {
+		from, to = now.Add(-servingMetricsRetention), now
+	}
+
+	series := d.metricsBufferOf(ctx).query(func(s metricSeries) bool {
+		return slices.Contains(params.Names, s.Labels["endpoint"]) &&
+			(len(params.Metrics) == 0 || slices.Contains(params.Metrics, s.Name))
+	}, from, to)
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(buildServingMetricsFrames(series), executed...),
+	}
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/serving"
+)
+
+const testMetricsExposition = `# TYPE request_count_total counter
+request_count_total{served_entity_name="model-1"} 10
+# TYPE cpu_usage_percentage gauge
+cpu_usage_percentage{served_entity_name="model-1",endpoint="other"} 42.5
+# TYPE request_latency_ms histogram
+request_latency_ms_bucket{le="100"} 3
+request_latency_ms_bucket{le="+Inf"} 5
+request_latency_ms_sum 420
+request_latency_ms_count 5
+`
+
+func TestBuildServingEndpointsFrame(t *testing.T) {
+	t.Parallel()
+
+	endpoints := []serving.ServingEndpointDetailed{
+		{
+			Name:  "chat",
+			Id:    "e1",
+			State: &serving.EndpointState{Ready: serving.EndpointStateReadyReady},
+			Config: &serving.EndpointCoreConfigOutput{
+				ServedEntities: []serving.ServedEntityOutput{
+					{Name: "chat-1", EntityName: "main.models.chat", EntityVersion: "1", WorkloadSize: "Small", ScaleToZeroEnabled: true},
+					{Name: "chat-2", EntityName: "main.models.chat", EntityVersion: "2", WorkloadSize: "Medium"},
+				},
+				TrafficConfig: &serving.TrafficConfig{Routes: []serving.Route{
+					{ServedModelName: "chat-1", TrafficPercentage: 80},
+					{ServedModelName: "chat-2", TrafficPercentage: 20},
+				}},
+			},
+		},
+		{Name: "pending", Id: "e2"},
+	}
+
+	frame := buildServingEndpointsFrame(endpoints, testHost)
+	if frame.Rows() != 3 {
+		t.Fatalf("expected a row per served entity and one for the endpoint without entities, got %d", frame.Rows())
+	}
+
+	entity, _ := frame.FieldByName("Served Entity")
+	traffic, _ := frame.FieldByName("Traffic (%)")
+	if entity.At(1).(string) != "chat-2" || *traffic.At(0).(*int64) != 80 || *traffic.At(1).(*int64) != 20 {
+		t.Errorf("unexpected traffic split: %v, %v", traffic.At(0), traffic.At(1))
+	}
+
+	scaleToZero, _ := frame.FieldByName("Scale To Zero")
+	if !*scaleToZero.At(0).(*bool) || *scaleToZero.At(1).(*bool) {
+		t.Error("expected scale to zero only for the first entity")
+	}
+
+	name, _ := frame.FieldByName("Endpoint Name")
+	if name.At(2).(string) != "pending" || entity.At(2).(string) != "" || traffic.At(2).(*int64) != nil {
+		t.Error("expected an empty entity row for the endpoint without config")
+	}
+}
+
+func TestParseMetricsExposition(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	series, err := parseMetricsExposition(strings.NewReader(testMetricsExposition), "my-endpoint", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{}
+	for _, s := range series {
+		if s.Labels["endpoint"] != "my-endpoint" {
+			t.Errorf("expected endpoint label, got %v", s.Labels)
+		}
+
+		if s.Name == "cpu_usage_percentage" && s.Labels["exported_endpoint"] != "other" {
+			t.Errorf("expected the clashing label to be renamed, got %v", s.Labels)
+		}
+
+		values[s.Name+s.Labels["le"]] = s.Samples[0].Value
+	}
+
+	expected := map[string]float64{
+		"request_count_total":           10,
+		"cpu_usage_percentage":          42.5,
+		"request_latency_ms_bucket100":  3,
+		"request_latency_ms_bucket+Inf": 5,
+		"request_latency_ms_sum":        420,
+		"request_latency_ms_count":      5,
+	}
+
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("expected %s to be %v, got %v", name, value, values[name])
+		}
+	}
+}
+
+func TestMetricsBuffer(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	buffer := newMetricsBuffer(time.Hour)
+	scrape := func(at time.Time, value float64) {
+		series, err := parseMetricsExposition(strings.NewReader("cpu_usage_percentage "+formatFloat(value)+"\n"), "my-endpoint", at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buffer.add(series, at)
+	}
+
+	scrape(now.Add(-2*time.Hour), 1)
+	scrape(now.Add(-30*time.Minute), 2)
+	scrape(now.Add(-30*time.Minute), 3)
+	scrape(now, 4)
+
+	all := func(metricSeries) bool { return true }
+
+	t.Run("should drop samples older than the retention and duplicates", func(t *testing.T) {
+		series := buffer.query(all, now.Add(-3*time.Hour), now)
+		if len(series) != 1 || len(series[0].Samples) != 2 {
+			t.Fatalf("expected 2 samples, got %+v", series)
+		}
+
+		if series[0].Samples[0].Value != 2 || series[0].Samples[1].Value != 4 {
+			t.Errorf("unexpected samples: %+v", series[0].Samples)
+		}
+	})
+
+	t.Run("should only return samples within the range", func(t *testing.T) {
+		series := buffer.query(all, now.Add(-time.Minute), now)
+		frames := buildServingMetricsFrames(series)
+		if len(frames) != 1 || frames[0].Rows() != 1 {
+			t.Fatalf("expected one frame with 1 row")
+		}
+
+		if frames[0].Fields[1].Labels["endpoint"] != "my-endpoint" {
+			t.Errorf("unexpected labels: %v", frames[0].Fields[1].Labels)
+		}
+	})
+}
@@ -0,0 +1,228 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// tableHistoryMetrics maps each output column to the operation metrics that report it, by
+// operation. The metric names differ between operations, and the same name can mean something
+// else: numOutputRows of a MERGE also counts the copied rows, so only the target metrics are
+// read for it. Other operations (e.g. WRITE, UPDATE) use the "" entry, the first present
+// metric wins. Rewritten rows (numCopiedRows) are never reported.
+var tableHistoryMetrics = []struct {
+	Name    string
+	Metrics map[string][]string
+}{
+	{"Rows Inserted", map[string][]string{"": {"numOutputRows"}, "MERGE": {"numTargetRowsInserted"}}},
+	{"Rows Updated", map[string][]string{"": {"numUpdatedRows"}, "MERGE": {"numTargetRowsUpdated"}}},
+	{"Rows Deleted", map[string][]string{"": {"numDeletedRows"}, "MERGE": {"numTargetRowsDeleted"}}},
+	{"Files Added", map[string][]string{"": {"numFiles", "numAddedFiles"}, "MERGE": {"numTargetFilesAdded"}}},
+	{"Bytes Written", map[string][]string{"": {"numOutputBytes", "numAddedBytes"}, "MERGE": {"numTargetBytesAdded"}}},
+}
+
+type tableHistoryParams struct {
+	Table string `json:"table"`
+}
+
+// tableHistoryJob is the job struct of a DESCRIBE HISTORY row, set when the commit was
+// made by a job run.
+type tableHistoryJob struct {
+	JobID    string `json:"jobId"`
+	JobName  string `json:"jobName"`
+	JobRunID string `json:"jobRunId"`
+	RunID    string `json:"runId"`
+}
+
+type tableHistoryEntry struct {
+	Timestamp time.Time
+	Version   int64
+	Operation string
+	UserName  string
+	Job       tableHistoryJob
+	Metrics   map[string]string
+}
+
+func parseTableHistoryParams(_ backend.DataQuery, qm queryModel) (tableHistoryParams, error) {
+	var params tableHistoryParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	if params.Table == "" {
+		return params, fmt.Errorf("table is required")
+	}
+
+	return params, nil
+}
+
+// buildTableHistoryStatement quotes the table name, which can't be passed as a parameter.
+func buildTableHistoryStatement(params tableHistoryParams, limit int) (string, error) {
+	parts := strings.Split(params.Table, ".")
+	if len(parts) > 3 || slices.Contains(parts, "") {
+		return "", fmt.Errorf("invalid table name: %s", params.Table)
+	}
+
+	return fmt.Sprintf("DESCRIBE HISTORY %s LIMIT %d", quoteIdentifier(parts...), limit), nil
+}
+
+func parseTableHistory(result *statementResult) ([]tableHistoryEntry, error) {
+	entries := []tableHistoryEntry{}
+	for _, row := range result.Rows {
+		timestamp, err := parseStatementTime(result.value(row, "timestamp"))
+		if err != nil {
+			return nil, err
+		}
+
+		if timestamp == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(result.value(row, "version"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %v", err)
+		}
+
+		entry := tableHistoryEntry{
+			Timestamp: *timestamp,
+			Version:   version,
+			Operation: result.value(row, "operation"),
+			UserName:  result.value(row, "userName"),
+		}
+
+		if job := result.value(row, "job"); job != "" {
+			if err := json.Unmarshal([]byte(job), &entry.Job); err != nil {
+				return nil, fmt.Errorf("invalid job: %v", err)
+			}
+		}
+
+		if metrics := result.value(row, "operationMetrics"); metrics != "" {
+			if err := json.Unmarshal([]byte(metrics), &entry.Metrics); err != nil {
+				return nil, fmt.Errorf("invalid operation metrics: %v", err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseOptionalInt64 parses a numeric string, returning nil when it is empty or invalid.
+func parseOptionalInt64(value string) *int64 {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
+
+func buildTableHistoryFrame(table string, entries []tableHistoryEntry, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Table History",
+		data.NewField("Time", nil, []time.Time{}),
+		data.NewField("Version", nil, []int64{}),
+		data.NewField("Operation", nil, []string{}),
+		data.NewField("User", nil, []string{}),
+		withLinks(data.NewField("Job ID", nil, []*int64{}), jobLink(host)),
+		withLinks(data.NewField("Job Run ID", nil, []*int64{}), jobRunLink(host)),
+	)
+
+	labels := data.Labels{"table": table}
+	for _, metric := range tableHistoryMetrics {
+		frame.Fields = append(frame.Fields, data.NewField(metric.Name, labels, []*int64{}))
+	}
+
+	// sort results ascending by Version, DESCRIBE HISTORY returns the latest commit first
+	slices.SortFunc(entries, func(i, j tableHistoryEntry) int {
+		return cmp.Compare(i.Version, j.Version)
+	})
+
+	for _, entry := range entries {
+		row := []any{
+			entry.Timestamp,
+			entry.Version,
+			entry.Operation,
+			entry.UserName,
+			parseOptionalInt64(entry.Job.JobID),
+			parseOptionalInt64(entry.Job.JobRunID),
+		}
+
+		for _, metric := range tableHistoryMetrics {
+			names, ok := metric.Metrics[entry.Operation]
+			if !ok {
+				names = metric.Metrics[""]
+			}
+
+			var value *int64
+			for _, name := range names {
+				if v, ok := entry.Metrics[name]; ok {
+					value = parseOptionalInt64(v)
+					break
+				}
+			}
+
+			row = append(row, value)
+		}
+
+		frame.AppendRow(row...)
+	}
+
+	return frame
+}
+
+func (d *Datasource) queryTableHistory(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseTableHistoryParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	statement, err := buildTableHistoryStatement(params, qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to build statement: %v", err))
+	}
+
+	warehouseId, err := d.getWarehouseId()
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	result, err := executeStatement(ctx, w, warehouseId, statement, nil)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to describe table history: %v", err))
+	}
+
+	entries, err := parseTableHistory(result)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to parse table history: %v", err))
+	}
+
+	// DESCRIBE HISTORY can't filter by time, so commits outside the dashboard range are
+	// dropped here
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		entries = slices.DeleteFunc(entries, func(entry tableHistoryEntry) bool {
+			return entry.Timestamp.Before(query.TimeRange.From) || entry.Timestamp.After(query.TimeRange.To)
+		})
+	}
+
+	frame := buildTableHistoryFrame(params.Table, entries, w.Config.Host)
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{frame}, describeStatement(statement, nil)),
+	}
+}
@@ -0,0 +1,319 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	tableModeMetadata  = "metadata"
+	tableModeFreshness = "freshness"
+
+	// freshness is taken from last_altered in information_schema, or from the latest
+	// commit in the Delta log (one DESCRIBE HISTORY statement per table)
+	freshnessSourceInformationSchema = "information_schema"
+	freshnessSourceHistory           = "history"
+
+	// maximum number of DESCRIBE HISTORY statements run at the same time
+	tableHistoryFanOut = 8
+)
+
+type tableParams struct {
+	Mode            string `json:"mode,omitempty"`
+	Catalog         string `json:"catalog,omitempty"`
+	Schema          string `json:"schema,omitempty"`
+	Table           string `json:"table,omitempty"`
+	FreshnessSource string `json:"freshnessSource,omitempty"`
+	StaleAfter      string `json:"staleAfter,omitempty"`
+
+	staleAfter time.Duration
+}
+
+type tableInfo struct {
+	Catalog      string
+	Schema       string
+	Name         string
+	Type         string
+	Owner        string
+	Format       string
+	Comment      string
+	Created      *time.Time
+	LastAltered  *time.Time
+	LastCommit   *time.Time
+	FullName     string
+	IsDeltaTable bool
+}
+
+func parseTableParams(_ backend.DataQuery, qm queryModel) (tableParams, error) {
+	var params tableParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	switch params.Mode {
+	case "":
+		params.Mode = tableModeMetadata
+	case tableModeMetadata, tableModeFreshness:
+	default:
+		return params, fmt.Errorf("unknown mode: %s", params.Mode)
+	}
+
+	switch params.FreshnessSource {
+	case "":
+		params.FreshnessSource = freshnessSourceInformationSchema
+	case freshnessSourceInformationSchema, freshnessSourceHistory:
+	default:
+		return params, fmt.Errorf("unknown freshness source: %s", params.FreshnessSource)
+	}
+
+	if params.StaleAfter != "" {
+		staleAfter, err := time.ParseDuration(params.StaleAfter)
+		if err != nil {
+			return params, fmt.Errorf("invalid staleAfter: %v", err)
+		}
+
+		params.staleAfter = staleAfter
+	}
+
+	return params, nil
+}
+
+// likePattern converts a glob style pattern (e.g. sales_*) to a SQL LIKE pattern, matching
+// everything when empty. Literal %, _ and \ are escaped, so the pattern must be compared with
+// ESCAPE '\\'.
+func likePattern(pattern string) string {
+	if pattern == "" {
+		return "%"
+	}
+
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%", "?", "_").Replace(pattern)
+}
+
+func buildTablesStatement(params tableParams, limit int) (string, []sql.StatementParameterListItem) {
+	statement := fmt.Sprintf(`SELECT table_catalog, table_schema, table_name, table_type, table_owner,
+  data_source_format, comment, created, last_altered
+FROM system.information_schema.tables
+WHERE table_catalog LIKE :catalog ESCAPE '\\'
+  AND table_schema LIKE :schema ESCAPE '\\'
+  AND table_name LIKE :table ESCAPE '\\'
+  AND table_schema <> 'information_schema'
+ORDER BY table_catalog, table_schema, table_name
+LIMIT %d`, limit)
+
+	parameters := []sql.StatementParameterListItem{
+		stringParameter("catalog", likePattern(params.Catalog)),
+		stringParameter("schema", likePattern(params.Schema)),
+		stringParameter("table", likePattern(params.Table)),
+	}
+
+	return statement, parameters
+}
+
+func parseTables(result *statementResult) ([]tableInfo, error) {
+	tables := []tableInfo{}
+	for _, row := range result.Rows {
+		created, err := parseStatementTime(result.value(row, "created"))
+		if err != nil {
+			return nil, err
+		}
+
+		lastAltered, err := parseStatementTime(result.value(row, "last_altered"))
+		if err != nil {
+			return nil, err
+		}
+
+		table := tableInfo{
+			Catalog:     result.value(row, "table_catalog"),
+			Schema:      result.value(row, "table_schema"),
+			Name:        result.value(row, "table_name"),
+			Type:        result.value(row, "table_type"),
+			Owner:       result.value(row, "table_owner"),
+			Format:      result.value(row, "data_source_format"),
+			Comment:     result.value(row, "comment"),
+			Created:     created,
+			LastAltered: lastAltered,
+			LastCommit:  lastAltered,
+		}
+
+		table.FullName = strings.Join([]string{table.Catalog, table.Schema, table.Name}, ".")
+		table.IsDeltaTable = strings.EqualFold(table.Format, "DELTA") && !strings.Contains(table.Type, "VIEW")
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// fetchLastCommits replaces the last commit time of every Delta table with the timestamp of
+// the latest entry in its history. The statements run concurrently and are returned for the
+// query inspector.
+func fetchLastCommits(ctx context.Context, w *databricks.WorkspaceClient, warehouseId string, tables []tableInfo) ([]string, error) {
+	statements := make([]string, len(tables))
+	errs := make([]error, len(tables))
+	semaphore := make(chan struct{}, tableHistoryFanOut)
+
+	var wg sync.WaitGroup
+	for i, table := range tables {
+		if !table.IsDeltaTable {
+			continue
+		}
+
+		statements[i] = fmt.Sprintf("DESCRIBE HISTORY %s LIMIT 1", quoteIdentifier(table.Catalog, table.Schema, table.Name))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result, err := executeStatement(ctx, w, warehouseId, statements[i], nil)
+			if err != nil {
+				errs[i] = fmt.Errorf("describe history of %s: %w", table.FullName, err)
+				return
+			}
+
+			if len(result.Rows) == 0 {
+				return
+			}
+
+			tables[i].LastCommit, errs[i] = parseStatementTime(result.value(result.Rows[0], "timestamp"))
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(statements, func(statement string) bool { return statement == "" }), nil
+}
+
+func buildTablesFrame(tables []tableInfo, host string) *data.Frame {
+	frame := data.NewFrame("Databricks Tables",
+		withLinks(data.NewField("Table", nil, []string{}), tableLink(host)),
+		data.NewField("Catalog", nil, []string{}),
+		data.NewField("Schema", nil, []string{}),
+		data.NewField("Table Name", nil, []string{}),
+		data.NewField("Table Type", nil, []string{}),
+		data.NewField("Owner", nil, []string{}),
+		data.NewField("Format", nil, []string{}),
+		data.NewField("Created", nil, []*time.Time{}),
+		data.NewField("Last Updated", nil, []*time.Time{}),
+		data.NewField("Comment", nil, []string{}),
+	)
+
+	for _, table := range tables {
+		frame.AppendRow(
+			table.FullName,
+			table.Catalog,
+			table.Schema,
+			table.Name,
+			table.Type,
+			table.Owner,
+			table.Format,
+			table.Created,
+			table.LastAltered,
+			table.Comment,
+		)
+	}
+
+	return frame
+}
+
+// buildTableFreshnessFrames returns one numeric frame per table, so each table becomes a
+// separate alert instance. Without a threshold the value is the seconds since the last
+// commit, with a threshold it is 1 for stale tables and 0 otherwise. Tables without a
+// commit time (e.g. never written to) get a null value, which alert rules can match.
+func buildTableFreshnessFrames(tables []tableInfo, staleAfter time.Duration, now time.Time) []*data.Frame {
+	frames := []*data.Frame{}
+	for _, table := range tables {
+		var value *float64
+		if table.LastCommit != nil {
+			age := now.Sub(*table.LastCommit)
+			v := age.Seconds()
+			if staleAfter > 0 {
+				v = 0
+				if age > staleAfter {
+					v = 1
+				}
+			}
+
+			value = &v
+		}
+
+		labels := data.Labels{"table": table.FullName}
+		name := "Seconds Since Update"
+		if staleAfter > 0 {
+			labels["stale_after"] = staleAfter.String()
+			name = "Stale"
+		}
+
+		field := data.NewField(name, labels, []*float64{value})
+		frame := data.NewFrame(table.FullName, field)
+		frame.SetMeta(&data.FrameMeta{Type: data.FrameTypeNumericMulti})
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryTables(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseTableParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	warehouseId, err := d.getWarehouseId()
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	statement, parameters := buildTablesStatement(params, qm.Limit)
+	result, err := executeStatement(ctx, w, warehouseId, statement, parameters)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list tables: %v", err))
+	}
+
+	tables, err := parseTables(result)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to parse tables: %v", err))
+	}
+
+	if params.Mode == tableModeMetadata {
+		return backend.DataResponse{
+			Frames: withExecutedQuery([]*data.Frame{buildTablesFrame(tables, w.Config.Host)}, describeStatement(statement, parameters)),
+		}
+	}
+
+	executed := []string{describeStatement(statement, parameters)}
+	if params.FreshnessSource == freshnessSourceHistory {
+		statements, err := fetchLastCommits(ctx, w, warehouseId, tables)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to fetch table history: %v", err))
+		}
+
+		executed = append(executed, statements...)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(buildTableFreshnessFrames(tables, params.staleAfter, time.Now()), executed...),
+	}
+}
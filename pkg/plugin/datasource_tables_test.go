@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestParseTableParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should default to metadata mode", func(t *testing.T) {
+		params, err := parseTableParams(backend.DataQuery{}, queryModel{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if params.Mode != tableModeMetadata || params.FreshnessSource != freshnessSourceInformationSchema {
+			t.Errorf("unexpected defaults: %+v", params)
+		}
+	})
+
+	t.Run("should parse stale threshold", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"mode": "freshness", "staleAfter": "6h"}`)}
+		params, err := parseTableParams(backend.DataQuery{}, qm)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if params.staleAfter != 6*time.Hour {
+			t.Errorf("expected 6h threshold, got %v", params.staleAfter)
+		}
+	})
+
+	t.Run("should return error when threshold is invalid", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"staleAfter": "soon"}`)}
+		if _, err := parseTableParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	t.Parallel()
+
+	if quoted := quoteIdentifier("main", "sales", "order`s"); quoted != "`main`.`sales`.`order``s`" {
+		t.Errorf("unexpected quoted identifier: %s", quoted)
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	t.Parallel()
+
+	for pattern, want := range map[string]string{
+		"":           "%",
+		"sales_*":    `sales\_%`,
+		"10%_off?":   `10\%\_off_`,
+		`back\slash`: `back\\slash`,
+	} {
+		if got := likePattern(pattern); got != want {
+			t.Errorf("expected %q for %q, got %q", want, pattern, got)
+		}
+	}
+}
+
+func TestBuildTableFreshnessFrames(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	result := &statementResult{
+		Columns: []sql.ColumnInfo{{Name: "table_catalog"}, {Name: "table_schema"}, {Name: "table_name"}, {Name: "last_altered"}},
+		Rows: [][]string{
+			{"main", "sales", "orders", now.Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)},
+			{"main", "sales", "customers", now.Add(-10 * time.Hour).UTC().Format(time.RFC3339Nano)},
+			{"main", "sales", "unknown", ""},
+		},
+	}
+
+	tables, err := parseTables(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should return seconds since update without threshold", func(t *testing.T) {
+		frames := buildTableFreshnessFrames(tables, 0, now)
+		if len(frames) != 3 {
+			t.Fatalf("expected 3 frames, got %d", len(frames))
+		}
+
+		age := *frames[0].Fields[0].At(0).(*float64)
+		if age < 7199 || age > 7201 {
+			t.Errorf("expected age of two hours, got %v", age)
+		}
+
+		if frames[0].Fields[0].Labels["table"] != "main.sales.orders" {
+			t.Errorf("unexpected labels: %v", frames[0].Fields[0].Labels)
+		}
+	})
+
+	t.Run("should return null for tables without a commit time", func(t *testing.T) {
+		frames := buildTableFreshnessFrames(tables, 6*time.Hour, now)
+		if frames[2].Fields[0].Labels["table"] != "main.sales.unknown" || frames[2].Fields[0].At(0).(*float64) != nil {
+			t.Errorf("expected a null value for the unknown table")
+		}
+	})
+
+	t.Run("should flag stale tables with threshold", func(t *testing.T) {
+		frames := buildTableFreshnessFrames(tables, 6*time.Hour, now)
+		if *frames[0].Fields[0].At(0).(*float64) != 0 || *frames[1].Fields[0].At(0).(*float64) != 1 {
+			t.Errorf("expected only customers to be stale")
+		}
+	})
+}
+
+func TestBuildTableHistoryStatement(t *testing.T) {
+	t.Parallel()
+
+	statement, err := buildTableHistoryStatement(tableHistoryParams{Table: "main.sales.orders"}, 10)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if statement != "DESCRIBE HISTORY `main`.`sales`.`orders` LIMIT 10" {
+		t.Errorf("unexpected statement: %s", statement)
+	}
+
+	if _, err := buildTableHistoryStatement(tableHistoryParams{Table: "main..orders"}, 10); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBuildTableHistoryFrame(t *testing.T) {
+	t.Parallel()
+
+	result := &statementResult{
+		Columns: []sql.ColumnInfo{{Name: "version"}, {Name: "timestamp"}, {Name: "operation"}, {Name: "job"}, {Name: "operationMetrics"}},
+		Rows: [][]string{
+			{"2", "2025-01-02T00:00:00.000Z", "MERGE", `{"jobId":"11","jobRunId":"22"}`, `{"numTargetRowsInserted":"5","numTargetRowsUpdated":"3","numTargetRowsCopied":"40","numOutputRows":"48"}`},
+			{"1", "2025-01-01T00:00:00.000Z", "WRITE", "", `{"numOutputRows":"100","numOutputBytes":"2048","numFiles":"2"}`},
+			{"3", "2025-01-03T00:00:00.000Z", "UPDATE", "", `{"numUpdatedRows":"7","numCopiedRows":"90","numAddedFiles":"1"}`},
+		},
+	}
+
+	entries, err := parseTableHistory(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := buildTableHistoryFrame("main.sales.orders", entries, testHost)
+	if frame.Rows() != 3 {
+		t.Fatalf("expected 3 rows, got %d", frame.Rows())
+	}
+
+	inserted, _ := frame.FieldByName("Rows Inserted")
+	if *inserted.At(0).(*int64) != 100 || *inserted.At(1).(*int64) != 5 || inserted.At(2).(*int64) != nil {
+		t.Errorf("unexpected rows inserted: %v, %v, %v", inserted.At(0), inserted.At(1), inserted.At(2))
+	}
+
+	updated, _ := frame.FieldByName("Rows Updated")
+	if updated.At(0).(*int64) != nil || *updated.At(1).(*int64) != 3 || *updated.At(2).(*int64) != 7 {
+		t.Error("expected rows updated only for the merge and update")
+	}
+
+	jobId, _ := frame.FieldByName("Job ID")
+	if jobId.At(0).(*int64) != nil || *jobId.At(1).(*int64) != 11 {
+		t.Error("expected job id only for the merge")
+	}
+}
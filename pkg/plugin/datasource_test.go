@@ -9,6 +9,9 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+// testHost is the workspace host the frame builders link to in tests.
+const testHost = "https://example.com/"
+
 func TestQueryData(t *testing.T) {
 	ds := Datasource{}
 
@@ -158,7 +161,7 @@ func TestBuildJobRunFrame(t *testing.T) {
 		}
 
 		// TODO: assert values, for now we just trigger internal transformation
-		frame := buildJobRunFrame(runs)
+		frame := buildJobRunFrame(runs, testHost)
 		if frame == nil {
 			t.Error("expected frame to be returned")
 		}
@@ -168,6 +171,62 @@ func TestBuildJobRunFrame(t *testing.T) {
 			t.Error(err)
 		}
 	})
+
+	t.Run("should return null end time and duration for running runs", func(t *testing.T) {
+		runs := []jobs.BaseRun{
+			{
+				StartTime: 1000,
+				EndTime:   3000,
+				JobId:     1,
+				RunId:     10,
+				State:     &jobs.RunState{LifeCycleState: jobs.RunLifeCycleStateTerminated, ResultState: jobs.RunResultStateFailed},
+				Status: &jobs.RunStatus{
+					State:              jobs.RunLifecycleStateV2StateTerminated,
+					TerminationDetails: &jobs.TerminationDetails{Code: jobs.TerminationCodeCodeRunExecutionError},
+				},
+				RunDuration: 2000,
+			},
+			{
+				// a run without status, as returned for older runs
+				StartTime: 2000,
+				JobId:     1,
+				RunId:     11,
+				State:     &jobs.RunState{LifeCycleState: jobs.RunLifeCycleStateRunning},
+			},
+		}
+
+		frame := buildJobRunFrame(runs, testHost)
+
+		runId, _ := frame.FieldByName("Run ID")
+		if runId.At(1).(int64) != 11 {
+			t.Errorf("expected numeric run id 11, got %v", runId.At(1))
+		}
+
+		endTime, _ := frame.FieldByName("End Time")
+		if endTime.At(0).(*time.Time) == nil || endTime.At(1).(*time.Time) != nil {
+			t.Errorf("expected null end time for the running run only")
+		}
+
+		duration, _ := frame.FieldByName("Run Duration (milliseconds)")
+		if *duration.At(0).(*int64) != 2000 || duration.At(1).(*int64) != nil {
+			t.Errorf("expected null duration for the running run only")
+		}
+
+		status, _ := frame.FieldByName("Status")
+		if status.At(1).(string) != string(jobs.RunLifeCycleStateRunning) {
+			t.Errorf("expected status from the run state, got %v", status.At(1))
+		}
+
+		resultState, _ := frame.FieldByName("Result State")
+		terminationCode, _ := frame.FieldByName("Termination Code")
+		if *resultState.At(0).(*string) != "FAILED" || *terminationCode.At(0).(*string) != "RUN_EXECUTION_ERROR" {
+			t.Errorf("unexpected result state %v and termination code %v", resultState.At(0), terminationCode.At(0))
+		}
+
+		if resultState.At(1).(*string) != nil || terminationCode.At(1).(*string) != nil {
+			t.Error("expected null result state and termination code for the running run")
+		}
+	})
 }
 
 // TODO: Add tests for pipelines datasource
@@ -0,0 +1,226 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// usageGroupColumns maps the supported group by keys to columns of system.billing.usage.
+// Custom tags are grouped with a "tag:<key>" group by.
+var usageGroupColumns = map[string]string{
+	"sku":       "u.sku_name",
+	"workspace": "u.workspace_id",
+	"job":       "u.usage_metadata.job_id",
+	"pipeline":  "u.usage_metadata.dlt_pipeline_id",
+	"warehouse": "u.usage_metadata.warehouse_id",
+}
+
+var usageIntervals = []string{"HOUR", "DAY", "WEEK", "MONTH"}
+
+const usageTagPrefix = "tag:"
+
+// usageWithPricesSource joins usage records with the list price that was in effect when
+// the usage occurred.
+const usageWithPricesSource = `FROM system.billing.usage u
+LEFT JOIN system.billing.list_prices p
+  ON u.cloud = p.cloud
+  AND u.sku_name = p.sku_name
+  AND u.usage_unit = p.usage_unit
+  AND u.usage_start_time >= p.price_start_time
+  AND (p.price_end_time IS NULL OR u.usage_start_time < p.price_end_time)`
+
+type usageParams struct {
+	GroupBy  []string `json:"groupBy,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+
+	// workspaceIds limits account-level queries to the selected workspaces
+	workspaceIds []string
+}
+
+func parseUsageParams(query backend.DataQuery, qm queryModel) (usageParams, error) {
+	var params usageParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	for _, group := range params.GroupBy {
+		if _, ok := usageGroupColumns[group]; !ok && !strings.HasPrefix(group, usageTagPrefix) {
+			return params, fmt.Errorf("unsupported group by: %s", group)
+		}
+	}
+
+	params.Interval = strings.ToUpper(params.Interval)
+	if params.Interval == "" {
+		params.Interval = usageIntervalFor(sampleInterval(query))
+	}
+
+	if !slices.Contains(usageIntervals, params.Interval) {
+		return params, fmt.Errorf("unsupported interval: %s", params.Interval)
+	}
+
+	return params, nil
+}
+
+// usageIntervalFor picks the finest bucket that is at least as coarse as the dashboard
+// interval. Usage records are hourly, so finer buckets would be empty.
+func usageIntervalFor(step time.Duration) string {
+	switch {
+	case step <= time.Hour:
+		return "HOUR"
+	case step <= 24*time.Hour:
+		return "DAY"
+	case step <= 7*24*time.Hour:
+		return "WEEK"
+	default:
+		return "MONTH"
+	}
+}
+
+// buildUsageStatement builds the usage query. Group by keys are mapped to a fixed set of
+// columns, and tag keys and the time range are passed as parameters.
+func buildUsageStatement(params usageParams, query backend.DataQuery) (string, []sql.StatementParameterListItem) {
+	parameters := []sql.StatementParameterListItem{
+		timestampParameter("from", query.TimeRange.From),
+		timestampParameter("to", query.TimeRange.To),
+	}
+
+	groupColumns := []string{}
+	for i, group := range params.GroupBy {
+		column, ok := usageGroupColumns[group]
+		if !ok {
+			name := fmt.Sprintf("tag%d", i)
+			column = fmt.Sprintf("u.custom_tags[:%s]", name)
+			parameters = append(parameters, stringParameter(name, strings.TrimPrefix(group, usageTagPrefix)))
+		}
+
+		groupColumns = append(groupColumns, fmt.Sprintf("CAST(%s AS STRING) AS group_%d", column, i))
+	}
+
+	selectColumns := append([]string{fmt.Sprintf("date_trunc('%s', u.usage_start_time) AS time", params.Interval)}, groupColumns...)
+	groupBy := []string{}
+	for i := range selectColumns {
+		groupBy = append(groupBy, fmt.Sprintf("%d", i+1))
+	}
+
+	conditions := []string{"u.usage_start_time >= :from AND u.usage_start_time < :to"}
+	if filter, workspaceParameters := workspaceIdFilter("u.workspace_id", params.workspaceIds); filter != "" {
+		conditions = append(conditions, filter)
+		parameters = append(parameters, workspaceParameters...)
+	}
+
+	statement := fmt.Sprintf(`SELECT %s,
+  SUM(u.usage_quantity) AS dbus,
+  SUM(u.usage_quantity * p.pricing.default) AS list_cost
+%s
+WHERE %s
+GROUP BY %s
+ORDER BY 1`, strings.Join(selectColumns, ",\n  "), usageWithPricesSource, strings.Join(conditions, "\n  AND "), strings.Join(groupBy, ", "))
+
+	return statement, parameters
+}
+
+// buildUsageFrames converts the usage rows into one time series frame per group, labelled
+// with the group by keys.
+func buildUsageFrames(result *statementResult, params usageParams) ([]*data.Frame, error) {
+	frames := []*data.Frame{}
+	byGroup := map[string]*data.Frame{}
+
+	for _, row := range result.Rows {
+		t, err := parseStatementTime(result.value(row, "time"))
+		if err != nil {
+			return nil, err
+		}
+
+		dbus, err := parseStatementFloat(result.value(row, "dbus"))
+		if err != nil {
+			return nil, err
+		}
+
+		cost, err := parseStatementFloat(result.value(row, "list_cost"))
+		if err != nil {
+			return nil, err
+		}
+
+		if t == nil {
+			continue
+		}
+
+		labels := data.Labels{}
+		for i, group := range params.GroupBy {
+			labels[strings.TrimPrefix(group, usageTagPrefix)] = result.value(row, fmt.Sprintf("group_%d", i))
+		}
+
+		key := labels.String()
+		frame, ok := byGroup[key]
+		if !ok {
+			frame = data.NewFrame("Databricks Usage",
+				data.NewField("Time", nil, []time.Time{}),
+				data.NewField("DBUs", labels, []*float64{}),
+				data.NewField("Estimated List Cost", labels, []*float64{}),
+			)
+
+			byGroup[key] = frame
+			frames = append(frames, frame)
+		}
+
+		frame.AppendRow(*t, dbus, cost)
+	}
+
+	return frames, nil
+}
+
+func (d *Datasource) queryUsage(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseUsageParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	// account-level queries read the usage of every workspace at once, so it is always
+	// grouped by workspace
+	scope, accountLevel := workspaceScopeFromContext(ctx)
+	if accountLevel {
+		params.workspaceIds = scope.ids
+		if !slices.Contains(params.GroupBy, workspaceLabel) {
+			params.GroupBy = append(params.GroupBy, workspaceLabel)
+		}
+	}
+
+	warehouseId, err := d.getWarehouseId()
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	statement, parameters := buildUsageStatement(params, query)
+	result, err := executeStatement(ctx, w, warehouseId, statement, parameters)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to query usage: %v", err))
+	}
+
+	frames, err := buildUsageFrames(result, params)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to build usage frames: %v", err))
+	}
+
+	if accountLevel {
+		frames = scope.nameWorkspaces(frames)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames, describeStatement(statement, parameters)),
+	}
+}
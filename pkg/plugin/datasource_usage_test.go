@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestParseUsageParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should derive interval from the query", func(t *testing.T) {
+		query := backend.DataQuery{Interval: 6 * time.Hour}
+		params, err := parseUsageParams(query, queryModel{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if params.Interval != "DAY" {
+			t.Errorf("expected DAY interval, got %s", params.Interval)
+		}
+	})
+
+	t.Run("should reject unknown group by", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"groupBy": ["cluster"]}`)}
+		if _, err := parseUsageParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("should reject unknown interval", func(t *testing.T) {
+		qm := queryModel{ResourceParams: json.RawMessage(`{"interval": "minute"}`)}
+		if _, err := parseUsageParams(backend.DataQuery{}, qm); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBuildUsageStatement(t *testing.T) {
+	t.Parallel()
+
+	params := usageParams{GroupBy: []string{"sku", "tag:team"}, Interval: "DAY"}
+	statement, parameters := buildUsageStatement(params, backend.DataQuery{})
+
+	if !strings.Contains(statement, "CAST(u.sku_name AS STRING) AS group_0") {
+		t.Errorf("expected sku group column in statement: %s", statement)
+	}
+
+	if !strings.Contains(statement, "CAST(u.custom_tags[:tag1] AS STRING) AS group_1") {
+		t.Errorf("expected tag group column in statement: %s", statement)
+	}
+
+	if !strings.Contains(statement, "GROUP BY 1, 2, 3") {
+		t.Errorf("expected group by all selected keys: %s", statement)
+	}
+
+	if len(parameters) != 3 || parameters[2].Value != "team" {
+		t.Errorf("expected tag key to be passed as parameter, got %+v", parameters)
+	}
+
+	params.workspaceIds = []string{"1", "2"}
+	statement, parameters = buildUsageStatement(params, backend.DataQuery{})
+	if !strings.Contains(statement, "AND CAST(u.workspace_id AS STRING) IN (:workspace0, :workspace1)") || len(parameters) != 5 {
+		t.Errorf("expected workspace filter in statement: %s", statement)
+	}
+}
+
+func TestBuildUsageFrames(t *testing.T) {
+	t.Parallel()
+
+	result := &statementResult{
+		Columns: []sql.ColumnInfo{{Name: "time"}, {Name: "group_0"}, {Name: "dbus"}, {Name: "list_cost"}},
+		Rows: [][]string{
+			{"2025-01-01T00:00:00.000Z", "JOBS_COMPUTE", "10.5", "1.05"},
+			{"2025-01-01T00:00:00.000Z", "SQL_COMPUTE", "2", ""},
+			{"2025-01-02T00:00:00.000Z", "JOBS_COMPUTE", "3", "0.3"},
+		},
+	}
+
+	frames, err := buildUsageFrames(result, usageParams{GroupBy: []string{"sku"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+
+	if frames[0].Rows() != 2 || frames[0].Fields[1].Labels["sku"] != "JOBS_COMPUTE" {
+		t.Errorf("unexpected first frame: %v rows, labels %v", frames[0].Rows(), frames[0].Fields[1].Labels)
+	}
+
+	if frames[1].Fields[2].At(0).(*float64) != nil {
+		t.Error("expected missing price to be null")
+	}
+}
+
+func TestAddJobRunCostFields(t *testing.T) {
+	t.Parallel()
+
+	runs := []jobs.BaseRun{
+		{RunId: 1, StartTime: 2000, Status: &jobs.RunStatus{}},
+		{RunId: 2, StartTime: 1000, Status: &jobs.RunStatus{}},
+	}
+
+	t.Run("should add costs for matching runs", func(t *testing.T) {
+		dbus := 4.0
+		frame := buildJobRunFrame(slices.Clone(runs), testHost)
+		err := addJobRunCostFields(frame, map[int64]jobRunCost{1: {DBUs: &dbus}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		field, _ := frame.FieldByName("Estimated DBUs")
+		// runs are sorted by start time, so run 1 is the second row
+		if field.At(0).(*float64) != nil || *field.At(1).(*float64) != 4 {
+			t.Errorf("unexpected dbus: %v, %v", field.At(0), field.At(1))
+		}
+	})
+
+	t.Run("should add null columns without costs", func(t *testing.T) {
+		frame := buildJobRunFrame(slices.Clone(runs), testHost)
+		if err := addJobRunCostFields(frame, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		field, _ := frame.FieldByName("Estimated List Cost")
+		if field.Len() != 2 || field.At(0).(*float64) != nil {
+			t.Error("expected null cost column")
+		}
+	})
+}
+
+func TestBuildJobRunCostStatement(t *testing.T) {
+	t.Parallel()
+
+	statement, parameters := buildJobRunCostStatement([]jobs.BaseRun{{RunId: 1, StartTime: 1000}, {RunId: 2}}, "")
+	if parameters[1].Value != "1,2" {
+		t.Errorf("expected run ids to be batched, got %s", parameters[1].Value)
+	}
+
+	if parameters[0].Value != time.UnixMilli(1000).UTC().Format(time.RFC3339Nano) {
+		t.Errorf("expected lookup to start at the earliest run, got %s", parameters[0].Value)
+	}
+
+	if strings.Contains(statement, "workspace_id") || len(parameters) != 2 {
+		t.Errorf("expected no workspace filter in workspace mode: %s", statement)
+	}
+
+	statement, parameters = buildJobRunCostStatement([]jobs.BaseRun{{RunId: 1}}, "42")
+	if !strings.Contains(statement, "u.workspace_id = :workspace_id") || parameters[2].Value != "42" {
+		t.Errorf("expected workspace filter in account mode: %s", statement)
+	}
+}
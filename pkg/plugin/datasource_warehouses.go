@@ -0,0 +1,219 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	warehouseModeTable      = "table"
+	warehouseModeTimeSeries = "timeseries"
+)
+
+type warehouseParams struct {
+	Mode         string   `json:"mode,omitempty"`
+	WarehouseIDs []string `json:"warehouseIds,omitempty"`
+}
+
+func parseWarehouseParams(_ backend.DataQuery, qm queryModel) (warehouseParams, error) {
+	var params warehouseParams
+	if qm.ResourceParams != nil {
+		if err := json.Unmarshal(qm.ResourceParams, &params); err != nil {
+			return params, fmt.Errorf("failed to unmarshal query params: %v", err)
+		}
+	}
+
+	switch params.Mode {
+	case "":
+		params.Mode = warehouseModeTable
+	case warehouseModeTable, warehouseModeTimeSeries:
+	default:
+		return params, fmt.Errorf("unknown mode: %s", params.Mode)
+	}
+
+	return params, nil
+}
+
+func buildWarehouseLoadRequest(params warehouseParams, query backend.DataQuery) sql.ListQueryHistoryRequest {
+	req := sql.ListQueryHistoryRequest{
+		IncludeMetrics: true, // needed for the queue timestamps
+		MaxResults:     1000, // 1000 is the max limit for this API - per page
+		FilterBy: &sql.QueryFilter{
+			WarehouseIds: params.WarehouseIDs,
+		},
+	}
+
+	// apply time range filter, if set
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		req.FilterBy.QueryStartTimeRange = &sql.TimeRange{
+			StartTimeMs: query.TimeRange.From.UnixMilli(),
+			EndTimeMs:   query.TimeRange.To.UnixMilli(),
+		}
+	}
+
+	return req
+}
+
+func buildWarehousesFrame(warehouses []sql.EndpointInfo, host string) *data.Frame {
+	frame := data.NewFrame("Databricks SQL Warehouses",
+		withLinks(data.NewField("Warehouse ID", nil, []string{}), warehouseLink(host)),
+		data.NewField("Warehouse Name", nil, []string{}),
+		data.NewField("State", nil, []string{}),
+		data.NewField("Size", nil, []string{}),
+		data.NewField("Warehouse Type", nil, []string{}),
+		data.NewField("Serverless", nil, []bool{}),
+		data.NewField("Min Clusters", nil, []int64{}),
+		data.NewField("Max Clusters", nil, []int64{}),
+		data.NewField("Current Clusters", nil, []int64{}),
+		data.NewField("Active Sessions", nil, []int64{}),
+		data.NewField("Auto Stop (minutes)", nil, []int64{}),
+		data.NewField("Health", nil, []string{}),
+		data.NewField("Health Message", nil, []string{}),
+		data.NewField("Creator", nil, []string{}),
+	)
+
+	for _, warehouse := range warehouses {
+		var health, healthMessage string
+		if warehouse.Health != nil {
+			health = string(warehouse.Health.Status)
+			healthMessage = warehouse.Health.Summary
+			if healthMessage == "" {
+				healthMessage = warehouse.Health.Message
+			}
+		}
+
+		frame.AppendRow(
+			warehouse.Id,
+			warehouse.Name,
+			string(warehouse.State),
+			warehouse.ClusterSize,
+			string(warehouse.WarehouseType),
+			warehouse.EnableServerlessCompute,
+			int64(warehouse.MinNumClusters),
+			int64(warehouse.MaxNumClusters),
+			int64(warehouse.NumClusters),
+			warehouse.NumActiveSessions,
+			int64(warehouse.AutoStopMins),
+			health,
+			healthMessage,
+			warehouse.CreatorName,
+		)
+	}
+
+	return frame
+}
+
+// queryPhases returns when a query entered the queue (zero if it never queued), when it
+// started executing and when it ended. Queries that are still running end at now.
+func queryPhases(q sql.QueryInfo, now time.Time) (queued int64, started int64, ended int64) {
+	started = q.QueryStartTimeMs
+	if q.Metrics != nil {
+		for _, ts := range []int64{q.Metrics.ProvisioningQueueStartTimestamp, q.Metrics.OverloadingQueueStartTimestamp} {
+			if ts != 0 && (queued == 0 || ts < queued) {
+				queued = ts
+			}
+		}
+
+		if q.Metrics.QueryCompilationStartTimestamp != 0 {
+			started = q.Metrics.QueryCompilationStartTimestamp
+		}
+	}
+
+	ended = q.QueryEndTimeMs
+	if ended == 0 {
+		ended = now.UnixMilli()
+	}
+
+	return queued, started, ended
+}
+
+// buildWarehouseLoadFrames samples the number of running and queued queries on each
+// warehouse at every point in times, returning one frame per warehouse.
+func buildWarehouseLoadFrames(warehouses []sql.EndpointInfo, queries []sql.QueryInfo, times []time.Time, now time.Time) []*data.Frame {
+	frames := []*data.Frame{}
+	for _, warehouse := range warehouses {
+		labels := data.Labels{"warehouse_id": warehouse.Id, "warehouse_name": warehouse.Name}
+		running := make([]int64, len(times))
+		queued := make([]int64, len(times))
+
+		for _, q := range queries {
+			if q.WarehouseId != warehouse.Id {
+				continue
+			}
+
+			queuedAt, startedAt, endedAt := queryPhases(q, now)
+			for i, t := range times {
+				ms := t.UnixMilli()
+				switch {
+				case ms >= startedAt && ms < endedAt:
+					running[i]++
+				case queuedAt != 0 && ms >= queuedAt && ms < startedAt:
+					queued[i]++
+				}
+			}
+		}
+
+		frame := data.NewFrame(warehouse.Name,
+			data.NewField("Time", nil, times),
+			data.NewField("Running Queries", labels, running),
+			data.NewField("Queued Queries", labels, queued),
+		)
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func (d *Datasource) queryWarehouses(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	params, err := parseWarehouseParams(query, qm)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query params: %v", err))
+	}
+
+	w, err := d.getDatabricksClient(ctx, pCtx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+	}
+
+	warehouses, err := fetchMatching(ctx, w.Warehouses.List(ctx, sql.ListWarehousesRequest{}), qm.Limit, func(warehouse sql.EndpointInfo) bool {
+		return len(params.WarehouseIDs) == 0 || slices.Contains(params.WarehouseIDs, warehouse.Id)
+	})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list warehouses: %v", err))
+	}
+
+	if params.Mode == warehouseModeTable {
+		return backend.DataResponse{
+			Frames: withExecutedQuery([]*data.Frame{buildWarehousesFrame(warehouses, w.Config.Host)}, describeRequest("GET", "/api/2.0/sql/warehouses", nil)),
+		}
+	}
+
+	request := buildWarehouseLoadRequest(params, query)
+	queries, err := fetchWithLimit(ctx, listQueryHistory(w, request), qm.Limit)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list query history: %v", err))
+	}
+
+	frames := buildWarehouseLoadFrames(warehouses, queries, sampleTimes(query), time.Now())
+	if len(queries) == qm.Limit && len(frames) > 0 {
+		frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("only the first %d queries of the query history were read, so the load is undercounted; increase Max Results or narrow the time range", qm.Limit),
+		})
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery(frames,
+			describeRequest("GET", "/api/2.0/sql/warehouses", nil),
+			describeRequest("GET", "/api/2.0/sql/history/queries", request),
+		),
+	}
+}
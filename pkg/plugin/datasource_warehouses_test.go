@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestSampleTimes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should return no samples without a time range", func(t *testing.T) {
+		if len(sampleTimes(backend.DataQuery{})) != 0 {
+			t.Error("expected no samples")
+		}
+	})
+
+	t.Run("should sample by interval", func(t *testing.T) {
+		from := time.UnixMilli(0).Add(time.Hour)
+		query := backend.DataQuery{
+			Interval:  time.Minute,
+			TimeRange: backend.TimeRange{From: from, To: from.Add(10 * time.Minute)},
+		}
+
+		times := sampleTimes(query)
+		if len(times) != 11 {
+			t.Errorf("expected 11 samples, got %d", len(times))
+		}
+	})
+
+	t.Run("should respect max data points", func(t *testing.T) {
+		from := time.UnixMilli(0).Add(time.Hour)
+		query := backend.DataQuery{
+			Interval:      time.Second,
+			MaxDataPoints: 10,
+			TimeRange:     backend.TimeRange{From: from, To: from.Add(10 * time.Minute)},
+		}
+
+		times := sampleTimes(query)
+		if len(times) != 11 {
+			t.Errorf("expected 11 samples, got %d", len(times))
+		}
+	})
+
+	t.Run("should bucket the start of an unaligned range", func(t *testing.T) {
+		from := time.UnixMilli(0).Add(time.Hour + 30*time.Second)
+		query := backend.DataQuery{
+			Interval:  time.Minute,
+			TimeRange: backend.TimeRange{From: from, To: from.Add(10 * time.Minute)},
+		}
+
+		times := sampleTimes(query)
+		if len(times) != 11 || bucketIndex(times, from) != 0 {
+			t.Errorf("expected the range start in the first of 11 buckets, got %d samples", len(times))
+		}
+	})
+
+	t.Run("should not sample tiny ranges endlessly", func(t *testing.T) {
+		from := time.UnixMilli(0).Add(time.Hour)
+		query := backend.DataQuery{TimeRange: backend.TimeRange{From: from, To: from.Add(50 * time.Nanosecond)}}
+
+		if step := sampleInterval(query); step != minSampleInterval {
+			t.Errorf("expected minimum interval, got %v", step)
+		}
+
+		if times := sampleTimes(query); len(times) != 1 {
+			t.Errorf("expected a single sample, got %d", len(times))
+		}
+	})
+}
+
+func TestBuildWarehouseLoadFrames(t *testing.T) {
+	t.Parallel()
+
+	base := time.UnixMilli(0).Add(time.Hour)
+	at := func(minutes int) int64 {
+		return base.Add(time.Duration(minutes) * time.Minute).UnixMilli()
+	}
+
+	warehouses := []sql.EndpointInfo{{Id: "wh1", Name: "main"}}
+	queries := []sql.QueryInfo{
+		{
+			WarehouseId:      "wh1",
+			QueryStartTimeMs: at(0),
+			QueryEndTimeMs:   at(5),
+			Metrics: &sql.QueryMetrics{
+				OverloadingQueueStartTimestamp: at(0),
+				QueryCompilationStartTimestamp: at(2),
+			},
+		},
+		{
+			WarehouseId:      "wh1",
+			QueryStartTimeMs: at(1),
+		},
+		{
+			WarehouseId:      "other",
+			QueryStartTimeMs: at(0),
+		},
+	}
+
+	times := []time.Time{base, base.Add(3 * time.Minute), base.Add(6 * time.Minute)}
+	frames := buildWarehouseLoadFrames(warehouses, queries, times, base.Add(10*time.Minute))
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 frame, got %d", len(frames))
+	}
+
+	running, _ := frames[0].FieldByName("Running Queries")
+	queued, _ := frames[0].FieldByName("Queued Queries")
+
+	expectedRunning := []int64{0, 2, 1}
+	expectedQueued := []int64{1, 0, 0}
+	for i := range times {
+		if running.At(i).(int64) != expectedRunning[i] {
+			t.Errorf("sample %d: expected %d running, got %v", i, expectedRunning[i], running.At(i))
+		}
+
+		if queued.At(i).(int64) != expectedQueued[i] {
+			t.Errorf("sample %d: expected %d queued, got %v", i, expectedQueued[i], queued.At(i))
+		}
+	}
+}
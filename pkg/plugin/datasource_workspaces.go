@@ -0,0 +1,666 @@
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/provisioning"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/rayalex/databricks/pkg/models"
+)
+
+const (
+	defaultAccountHost = "https://accounts.cloud.databricks.com"
+
+	// the workspace list is refreshed at most this often, the workspace clients are kept
+	workspaceListTTL = 5 * time.Minute
+
+	// maximum number of workspaces queried at the same time
+	workspaceFanOut = 8
+
+	// workspaceLabel labels the series of account-level queries, workspaceField their rows
+	workspaceLabel = "workspace"
+	workspaceField = "Workspace"
+
+	// allWorkspaces targets every workspace of the account, as does an empty selection
+	allWorkspaces = "*"
+)
+
+// systemTableResourceTypes read account-wide system tables through the SQL warehouse, so in
+// account mode they run once, in the workspace of the warehouse, filtered to the selected
+// workspaces and labelled with the workspace of each row.
+var systemTableResourceTypes = []string{
+	resourceTypeUsage,
+	resourceTypeAuditEvents,
+}
+
+// metastoreResourceTypes read the Unity Catalog metastore attached to a single workspace,
+// which is not the metastore of every workspace of the account, so they are rejected in
+// account mode.
+var metastoreResourceTypes = []string{
+	resourceTypeTables,
+	resourceTypeTableHistory,
+}
+
+type workspaceContextKey struct{}
+
+type workspaceScopeContextKey struct{}
+
+// workspaceScope limits a query of account-wide system tables to the selected workspaces and
+// names the workspaces of the account in its results.
+type workspaceScope struct {
+	// ids of the selected workspaces, empty when every workspace is selected
+	ids   []string
+	names map[string]string
+}
+
+// workspaceEntry is a workspace of the account with its client and the caches that are kept
+// per workspace, since job ids and endpoint names are only unique within a workspace.
+type workspaceEntry struct {
+	workspace     provisioning.Workspace
+	client        *databricks.WorkspaceClient
+	runHistory    *runHistoryCache
+	metricsBuffer *metricsBuffer
+}
+
+// workspaceClients lists the workspaces of an account and caches a client per workspace for
+// the lifetime of the datasource instance.
+type workspaceClients struct {
+	mu        sync.Mutex
+	account   *databricks.AccountClient
+	list      []provisioning.Workspace
+	fetchedAt time.Time
+	entries   map[int64]*workspaceEntry
+
+	// warehouse is the workspace of the configured SQL warehouse, once found
+	warehouse *workspaceEntry
+}
+
+func newWorkspaceClients() *workspaceClients {
+	return &workspaceClients{entries: map[int64]*workspaceEntry{}}
+}
+
+// isAccountMode reports whether the datasource is configured for an account rather than a
+// single workspace.
+func isAccountMode(config *models.PluginSettings) bool {
+	return config.AccountId != ""
+}
+
+// accountClient returns the account console client, creating it on first use. It must be
+// called with the lock held.
+func (c *workspaceClients) accountClient(config *models.PluginSettings) (*databricks.AccountClient, error) {
+	if c.account != nil {
+		return c.account, nil
+	}
+
+	host := config.AccountHost
+	if host == "" {
+		host = defaultAccountHost
+	}
+
+	account, err := databricks.NewAccountClient(&databricks.Config{
+		Host:         host,
+		AccountID:    config.AccountId,
+		ClientID:     config.Secrets.ClientId,
+		ClientSecret: config.Secrets.ClientSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.account = account
+	return account, nil
+}
+
+// workspaces returns the workspaces of the account sorted by name, listing them again once
+// the cached list is older than workspaceListTTL. The lock isn't held while listing, so
+// concurrent queries may list them at the same time.
+func (c *workspaceClients) workspaces(ctx context.Context, config *models.PluginSettings, now time.Time) ([]provisioning.Workspace, error) {
+	c.mu.Lock()
+	list, fetchedAt := c.list, c.fetchedAt
+	account, err := c.accountClient(config)
+	c.mu.Unlock()
+
+	if list != nil && now.Sub(fetchedAt) < workspaceListTTL {
+		return list, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err = account.Workspaces.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(list, func(i, j provisioning.Workspace) int {
+		return cmp.Compare(i.WorkspaceName, j.WorkspaceName)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.list, c.fetchedAt = list, now
+	return list, nil
+}
+
+// warehouseEntry returns the workspace of the configured SQL warehouse, looking the warehouse
+// up in every running workspace on first use.
+func (c *workspaceClients) warehouseEntry(ctx context.Context, config *models.PluginSettings, workspaces []provisioning.Workspace) (*workspaceEntry, error) {
+	c.mu.Lock()
+	warehouse := c.warehouse
+	c.mu.Unlock()
+
+	if warehouse != nil {
+		return warehouse, nil
+	}
+
+	if config.WarehouseId == "" {
+		return nil, fmt.Errorf("no SQL warehouse configured for this datasource")
+	}
+
+	running, _ := selectWorkspaces(workspaces, nil)
+	for _, workspace := range running {
+		entry, err := c.entry(config, workspace)
+		if err != nil {
+			continue
+		}
+
+		if _, err := entry.client.Warehouses.GetById(ctx, config.WarehouseId); err != nil {
+			continue
+		}
+
+		c.mu.Lock()
+		c.warehouse = entry
+		c.mu.Unlock()
+
+		return entry, nil
+	}
+
+	return nil, fmt.Errorf("SQL warehouse %s not found in any running workspace", config.WarehouseId)
+}
+
+// entry returns the cached client of a workspace, creating it on first use.
+func (c *workspaceClients) entry(config *models.PluginSettings, workspace provisioning.Workspace) (*workspaceEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[workspace.WorkspaceId]; ok {
+		return entry, nil
+	}
+
+	account, err := c.accountClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := account.GetWorkspaceClient(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &workspaceEntry{
+		workspace:     workspace,
+		client:        client,
+		runHistory:    newRunHistoryCache(),
+		metricsBuffer: newMetricsBuffer(servingMetricsRetention),
+	}
+	c.entries[workspace.WorkspaceId] = entry
+
+	return entry, nil
+}
+
+// workspaceURL returns the URL of a workspace of the account.
+func (c *workspaceClients) workspaceURL(config *models.PluginSettings, workspace provisioning.Workspace) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	account, err := c.accountClient(config)
+	if err != nil {
+		return "", err
+	}
+
+	return account.Config.Environment().DeploymentURL(workspace.DeploymentName), nil
+}
+
+// selectWorkspaces returns the workspaces matching the selection, by id, name or deployment
+// name. An empty selection, or "*", selects every running workspace.
+func selectWorkspaces(workspaces []provisioning.Workspace, selection []string) ([]provisioning.Workspace, error) {
+	if len(selection) == 0 || slices.Contains(selection, allWorkspaces) {
+		return slices.DeleteFunc(slices.Clone(workspaces), func(w provisioning.Workspace) bool {
+			return w.WorkspaceStatus != "" && w.WorkspaceStatus != provisioning.WorkspaceStatusRunning
+		}), nil
+	}
+
+	selected := []provisioning.Workspace{}
+	for _, s := range selection {
+		i := slices.IndexFunc(workspaces, func(w provisioning.Workspace) bool {
+			return strconv.FormatInt(w.WorkspaceId, 10) == s || w.WorkspaceName == s || w.DeploymentName == s
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("unknown workspace: %s", s)
+		}
+
+		if !slices.ContainsFunc(selected, func(w provisioning.Workspace) bool { return w.WorkspaceId == workspaces[i].WorkspaceId }) {
+			selected = append(selected, workspaces[i])
+		}
+	}
+
+	return selected, nil
+}
+
+// newWorkspaceScope returns the scope of a selection. An empty selection, or "*", selects
+// every workspace, including deleted ones whose rows are still in the system tables.
+func newWorkspaceScope(workspaces []provisioning.Workspace, selection []string) (*workspaceScope, error) {
+	scope := &workspaceScope{names: map[string]string{}}
+	for _, workspace := range workspaces {
+		scope.names[strconv.FormatInt(workspace.WorkspaceId, 10)] = workspace.WorkspaceName
+	}
+
+	if len(selection) == 0 || slices.Contains(selection, allWorkspaces) {
+		return scope, nil
+	}
+
+	selected, err := selectWorkspaces(workspaces, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workspace := range selected {
+		scope.ids = append(scope.ids, strconv.FormatInt(workspace.WorkspaceId, 10))
+	}
+
+	return scope, nil
+}
+
+// name returns the name of a workspace by id, or the id of workspaces that are not listed.
+func (s *workspaceScope) name(id string) string {
+	if name := s.names[id]; name != "" {
+		return name
+	}
+
+	return id
+}
+
+// nameWorkspaces replaces the workspace ids in the workspace labels of the frames with the
+// workspace names.
+func (s *workspaceScope) nameWorkspaces(frames []*data.Frame) []*data.Frame {
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if id, ok := field.Labels[workspaceLabel]; ok {
+				field.Labels[workspaceLabel] = s.name(id)
+			}
+		}
+	}
+
+	return frames
+}
+
+// workspaceIdFilter builds the condition limiting a system table to the given workspaces, or
+// an empty condition when there are none. The ids are passed as parameters.
+func workspaceIdFilter(column string, ids []string) (string, []sql.StatementParameterListItem) {
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	names := []string{}
+	parameters := []sql.StatementParameterListItem{}
+	for i, id := range ids {
+		name := fmt.Sprintf("workspace%d", i)
+		names = append(names, ":"+name)
+		parameters = append(parameters, stringParameter(name, id))
+	}
+
+	return fmt.Sprintf("CAST(%s AS STRING) IN (%s)", column, strings.Join(names, ", ")), parameters
+}
+
+func withWorkspaceScope(ctx context.Context, scope *workspaceScope) context.Context {
+	return context.WithValue(ctx, workspaceScopeContextKey{}, scope)
+}
+
+func workspaceScopeFromContext(ctx context.Context) (*workspaceScope, bool) {
+	scope, ok := ctx.Value(workspaceScopeContextKey{}).(*workspaceScope)
+	return scope, ok
+}
+
+func withWorkspace(ctx context.Context, entry *workspaceEntry) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, entry)
+}
+
+func workspaceFromContext(ctx context.Context) (*workspaceEntry, bool) {
+	entry, ok := ctx.Value(workspaceContextKey{}).(*workspaceEntry)
+	return entry, ok
+}
+
+// runHistoryOf returns the run history cache of the workspace of an account-level query, or
+// the cache of the datasource otherwise.
+func (d *Datasource) runHistoryOf(ctx context.Context) *runHistoryCache {
+	if entry, ok := workspaceFromContext(ctx); ok {
+		return entry.runHistory
+	}
+
+	return d.runHistory
+}
+
+// getWarehouseClient returns the client of the workspace of the SQL warehouse: the
+// datasource's workspace, or in account mode the workspace the warehouse was found in.
+func (d *Datasource) getWarehouseClient(ctx context.Context, pCtx backend.PluginContext) (*databricks.WorkspaceClient, error) {
+	config, err := models.LoadPluginSettings(d.settings)
+	if err != nil {
+		return nil, fmt.Errorf("load plugin settings: %v", err)
+	}
+
+	if !isAccountMode(config) {
+		return d.getDatabricksClient(ctx, pCtx)
+	}
+
+	workspaces, err := d.workspaceClients.workspaces(ctx, config, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %v", err)
+	}
+
+	entry, err := d.workspaceClients.warehouseEntry(ctx, config, workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.client, nil
+}
+
+// metricsBufferOf returns the serving metrics buffer of the workspace of an account-level
+// query, or the buffer of the datasource otherwise.
+func (d *Datasource) metricsBufferOf(ctx context.Context) *metricsBuffer {
+	if entry, ok := workspaceFromContext(ctx); ok {
+		return entry.metricsBuffer
+	}
+
+	return d.metricsBuffer
+}
+
+// queryWorkspaces runs a query against every selected workspace of the account concurrently,
+// labels the results with the workspace and merges them. Workspaces that fail are reported
+// as warnings, unless all of them fail. Queries of system tables run once, in the workspace
+// of the SQL warehouse, and queries of the metastore are rejected.
+func (d *Datasource) queryWorkspaces(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, qm queryModel, config *models.PluginSettings) backend.DataResponse {
+	workspaces, err := d.workspaceClients.workspaces(ctx, config, time.Now())
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list workspaces: %v", err))
+	}
+
+	if slices.Contains(metastoreResourceTypes, qm.ResourceType) {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("%s reads the metastore of a single workspace and is not supported in account mode, use a workspace datasource instead", qm.ResourceType))
+	}
+
+	if slices.Contains(systemTableResourceTypes, qm.ResourceType) {
+		scope, err := newWorkspaceScope(workspaces, qm.Workspaces)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+
+		entry, err := d.workspaceClients.warehouseEntry(ctx, config, workspaces)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+
+		res := d.queryResource(withWorkspaceScope(withWorkspace(ctx, entry), scope), pCtx, query, qm)
+		for _, frame := range res.Frames {
+			if frame.Meta != nil && frame.Meta.ExecutedQueryString != "" {
+				frame.Meta.ExecutedQueryString = "-- workspace: " + entry.workspace.WorkspaceName + "\n" + frame.Meta.ExecutedQueryString
+			}
+		}
+
+		return res
+	}
+
+	selected, err := selectWorkspaces(workspaces, qm.Workspaces)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	if len(selected) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "no workspaces selected")
+	}
+
+	responses := make([]backend.DataResponse, len(selected))
+	semaphore := make(chan struct{}, workspaceFanOut)
+
+	var wg sync.WaitGroup
+	for i, workspace := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			entry, err := d.workspaceClients.entry(config, workspace)
+			if err != nil {
+				responses[i] = backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get databricks client: %v", err))
+				return
+			}
+
+			responses[i] = d.queryResource(withWorkspace(ctx, entry), pCtx, query, qm)
+		}()
+	}
+	wg.Wait()
+
+	frames := []*data.Frame{}
+	notices := []data.Notice{}
+	var firstErr *backend.DataResponse
+	for i, res := range responses {
+		name := selected[i].WorkspaceName
+		if res.Error != nil {
+			if firstErr == nil {
+				firstErr = &backend.DataResponse{Error: fmt.Errorf("%s: %w", name, res.Error), Status: res.Status}
+			}
+
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("query failed in workspace %s: %v", name, res.Error),
+			})
+			continue
+		}
+
+		for _, frame := range res.Frames {
+			frames = append(frames, labelWorkspaceFrame(frame, name))
+		}
+	}
+
+	if len(frames) == 0 && firstErr != nil {
+		return *firstErr
+	}
+
+	frames = mergeWorkspaceFrames(frames)
+	if len(frames) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return backend.DataResponse{Frames: frames}
+}
+
+// labelWorkspaceFrame labels a frame with the workspace it came from: series get a workspace
+// label, log lines a workspace label in their labels field, and tables a Workspace column.
+// Live streams are bound to a single workspace, so the stream channel is dropped.
+func labelWorkspaceFrame(frame *data.Frame, workspace string) *data.Frame {
+	if frame.Meta != nil {
+		frame.Meta.Channel = ""
+		if frame.Meta.ExecutedQueryString != "" {
+			frame.Meta.ExecutedQueryString = "-- workspace: " + workspace + "\n" + frame.Meta.ExecutedQueryString
+		}
+	}
+
+	rows, _ := frame.RowLen()
+
+	if frame.Meta != nil && frame.Meta.Type == data.FrameTypeLogLines {
+		if field, _ := frame.FieldByName("labels"); field != nil {
+			for row := range rows {
+				labels := map[string]string{}
+				if raw, ok := field.At(row).(json.RawMessage); ok {
+					_ = json.Unmarshal(raw, &labels)
+				}
+				labels[workspaceLabel] = workspace
+
+				raw, err := json.Marshal(labels)
+				if err == nil {
+					field.Set(row, json.RawMessage(raw))
+				}
+			}
+		}
+
+		return frame
+	}
+
+	series := frame.TimeSeriesSchema().Type == data.TimeSeriesTypeWide
+	for _, field := range frame.Fields {
+		if len(field.Labels) > 0 {
+			series = true
+		}
+	}
+
+	if series {
+		for _, field := range frame.Fields {
+			if field.Type().Time() {
+				continue
+			}
+
+			if field.Labels == nil {
+				field.Labels = data.Labels{}
+			}
+			field.Labels[workspaceLabel] = workspace
+		}
+
+		return frame
+	}
+
+	names := make([]string, rows)
+	for row := range names {
+		names[row] = workspace
+	}
+
+	frame.Fields = append([]*data.Field{data.NewField(workspaceField, nil, names)}, frame.Fields...)
+	return frame
+}
+
+// mergeWorkspaceFrames appends the rows of tables with the same name and fields into the
+// first of them, so a table lists the rows of every workspace. Series are kept as they are.
+func mergeWorkspaceFrames(frames []*data.Frame) []*data.Frame {
+	merged := []*data.Frame{}
+	byKey := map[string]*data.Frame{}
+	for _, frame := range frames {
+		if len(frame.Fields) == 0 || frame.Fields[0].Name != workspaceField {
+			merged = append(merged, frame)
+			continue
+		}
+
+		key := frameSignature(frame)
+		target, ok := byKey[key]
+		if !ok {
+			byKey[key] = frame
+			merged = append(merged, frame)
+			continue
+		}
+
+		rows, _ := frame.RowLen()
+		for row := range rows {
+			target.AppendRow(frame.RowCopy(row)...)
+		}
+
+		if frame.Meta != nil {
+			target.AppendNotices(frame.Meta.Notices...)
+			if frame.Meta.ExecutedQueryString != "" {
+				if target.Meta.ExecutedQueryString != "" {
+					target.Meta.ExecutedQueryString += "\n"
+				}
+				target.Meta.ExecutedQueryString += frame.Meta.ExecutedQueryString
+			}
+		}
+	}
+
+	return merged
+}
+
+// frameSignature identifies frames by name and field names and types.
+func frameSignature(frame *data.Frame) string {
+	parts := []string{frame.Name}
+	for _, field := range frame.Fields {
+		parts = append(parts, field.Name+":"+field.Type().String())
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+// buildWorkspacesFrame lists the workspaces of the account, e.g. for a template variable.
+func buildWorkspacesFrame(workspaces []provisioning.Workspace, urls []string) *data.Frame {
+	frame := data.NewFrame("Databricks Workspaces",
+		data.NewField("Workspace ID", nil, []int64{}),
+		data.NewField("Workspace Name", nil, []string{}),
+		data.NewField("Deployment Name", nil, []string{}),
+		data.NewField("Status", nil, []string{}),
+		data.NewField("Cloud", nil, []string{}),
+		data.NewField("Region", nil, []string{}),
+		data.NewField("Creation Time", nil, []*time.Time{}),
+		data.NewField("URL", nil, []string{}),
+	)
+
+	for i, w := range workspaces {
+		region := w.AwsRegion
+		if region == "" {
+			region = w.Location
+		}
+
+		frame.AppendRow(
+			w.WorkspaceId,
+			w.WorkspaceName,
+			w.DeploymentName,
+			string(w.WorkspaceStatus),
+			w.Cloud,
+			region,
+			optionalUnixMilli(w.CreationTime),
+			urls[i],
+		)
+	}
+
+	return frame
+}
+
+func (d *Datasource) queryWorkspaceList(ctx context.Context, _ backend.PluginContext, _ backend.DataQuery, _ queryModel) backend.DataResponse {
+	config, err := models.LoadPluginSettings(d.settings)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("load plugin settings: %v", err))
+	}
+
+	if !isAccountMode(config) {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "workspaces can only be listed in account mode")
+	}
+
+	workspaces, err := d.workspaceClients.workspaces(ctx, config, time.Now())
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to list workspaces: %v", err))
+	}
+
+	urls := []string{}
+	for _, workspace := range workspaces {
+		url, err := d.workspaceClients.workspaceURL(config, workspace)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get account client: %v", err))
+		}
+
+		urls = append(urls, url)
+	}
+
+	return backend.DataResponse{
+		Frames: withExecutedQuery([]*data.Frame{buildWorkspacesFrame(workspaces, urls)},
+			describeRequest("GET", fmt.Sprintf("/api/2.0/accounts/%s/workspaces", config.AccountId), nil)),
+	}
+}
@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/provisioning"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestSelectWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	workspaces := []provisioning.Workspace{
+		{WorkspaceId: 1, WorkspaceName: "analytics", DeploymentName: "dbc-1", WorkspaceStatus: provisioning.WorkspaceStatusRunning},
+		{WorkspaceId: 2, WorkspaceName: "ml", DeploymentName: "dbc-2", WorkspaceStatus: provisioning.WorkspaceStatusRunning},
+		{WorkspaceId: 3, WorkspaceName: "sandbox", DeploymentName: "dbc-3", WorkspaceStatus: provisioning.WorkspaceStatusProvisioning},
+	}
+
+	t.Run("should select running workspaces by default", func(t *testing.T) {
+		for _, selection := range [][]string{nil, {"*"}} {
+			selected, err := selectWorkspaces(workspaces, selection)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(selected) != 2 || selected[1].WorkspaceName != "ml" {
+				t.Errorf("unexpected selection for %v: %+v", selection, selected)
+			}
+		}
+	})
+
+	t.Run("should select by id, name or deployment", func(t *testing.T) {
+		selected, err := selectWorkspaces(workspaces, []string{"3", "ml", "dbc-2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(selected) != 2 || selected[0].WorkspaceId != 3 || selected[1].WorkspaceId != 2 {
+			t.Errorf("unexpected selection: %+v", selected)
+		}
+	})
+
+	t.Run("should fail on unknown workspaces", func(t *testing.T) {
+		if _, err := selectWorkspaces(workspaces, []string{"prod"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestWorkspaceScope(t *testing.T) {
+	t.Parallel()
+
+	workspaces := []provisioning.Workspace{
+		{WorkspaceId: 1, WorkspaceName: "analytics"},
+		{WorkspaceId: 2, WorkspaceName: "ml"},
+	}
+
+	t.Run("should not filter when every workspace is selected", func(t *testing.T) {
+		scope, err := newWorkspaceScope(workspaces, []string{"*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if filter, _ := workspaceIdFilter("workspace_id", scope.ids); filter != "" {
+			t.Errorf("expected no filter, got %s", filter)
+		}
+	})
+
+	t.Run("should filter by the ids of the selected workspaces", func(t *testing.T) {
+		scope, err := newWorkspaceScope(workspaces, []string{"ml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		filter, parameters := workspaceIdFilter("workspace_id", scope.ids)
+		if filter != "CAST(workspace_id AS STRING) IN (:workspace0)" || len(parameters) != 1 || parameters[0].Value != "2" {
+			t.Errorf("unexpected filter: %s %+v", filter, parameters)
+		}
+	})
+
+	t.Run("should name workspaces by id", func(t *testing.T) {
+		scope, err := newWorkspaceScope(workspaces, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frames := scope.nameWorkspaces([]*data.Frame{
+			data.NewFrame("usage", data.NewField("DBUs", data.Labels{workspaceLabel: "1"}, []float64{1})),
+			data.NewFrame("usage", data.NewField("DBUs", data.Labels{workspaceLabel: "9"}, []float64{1})),
+		})
+
+		if frames[0].Fields[0].Labels[workspaceLabel] != "analytics" || frames[1].Fields[0].Labels[workspaceLabel] != "9" {
+			t.Errorf("unexpected labels: %v %v", frames[0].Fields[0].Labels, frames[1].Fields[0].Labels)
+		}
+	})
+
+	t.Run("should fail on unknown workspaces", func(t *testing.T) {
+		if _, err := newWorkspaceScope(workspaces, []string{"prod"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestLabelWorkspaceFrame(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should add a workspace column to tables", func(t *testing.T) {
+		frame := data.NewFrame("Databricks Job Runs",
+			data.NewField("Start Time", nil, []time.Time{time.Now()}),
+			data.NewField("Run Name", nil, []string{"etl"}),
+		)
+		frame.SetMeta(&data.FrameMeta{Channel: "ds/uid/jobs", ExecutedQueryString: "GET /api/2.2/jobs/runs/list"})
+
+		frame = labelWorkspaceFrame(frame, "analytics")
+		if frame.Fields[0].Name != workspaceField || frame.Fields[0].At(0).(string) != "analytics" {
+			t.Errorf("unexpected first field: %s", frame.Fields[0].Name)
+		}
+
+		if frame.Meta.Channel != "" || frame.Meta.ExecutedQueryString != "-- workspace: analytics\nGET /api/2.2/jobs/runs/list" {
+			t.Errorf("unexpected meta: %+v", frame.Meta)
+		}
+	})
+
+	t.Run("should label series", func(t *testing.T) {
+		frame := data.NewFrame("latency",
+			data.NewField("Time", nil, []time.Time{time.Now()}),
+			data.NewField("Value", data.Labels{"endpoint": "chat"}, []float64{1}),
+		)
+
+		frame = labelWorkspaceFrame(frame, "ml")
+		if len(frame.Fields) != 2 || frame.Fields[1].Labels[workspaceLabel] != "ml" || frame.Fields[1].Labels["endpoint"] != "chat" {
+			t.Errorf("unexpected labels: %v", frame.Fields[1].Labels)
+		}
+	})
+
+	t.Run("should label log lines", func(t *testing.T) {
+		frame, err := buildLogsFrame("Databricks Job Run Logs", []logEntry{{Time: time.Now(), Body: "done", Level: "info", Labels: map[string]string{"run_id": "1"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frame = labelWorkspaceFrame(frame, "analytics")
+
+		field, _ := frame.FieldByName("labels")
+		labels := map[string]string{}
+		if err := json.Unmarshal(field.At(0).(json.RawMessage), &labels); err != nil {
+			t.Fatal(err)
+		}
+
+		if labels[workspaceLabel] != "analytics" || labels["run_id"] != "1" || len(frame.Fields) != 4 {
+			t.Errorf("unexpected labels: %v", labels)
+		}
+	})
+}
+
+func TestMergeWorkspaceFrames(t *testing.T) {
+	t.Parallel()
+
+	table := func(workspace string, names ...string) *data.Frame {
+		frame := data.NewFrame("Databricks Pipelines", data.NewField("Name", nil, names))
+		frame.AppendNotices(data.Notice{Text: workspace})
+		return labelWorkspaceFrame(frame, workspace)
+	}
+
+	series := labelWorkspaceFrame(data.NewFrame("series",
+		data.NewField("Time", nil, []time.Time{time.Now()}),
+		data.NewField("Value", nil, []float64{1}),
+	), "ml")
+
+	frames := mergeWorkspaceFrames([]*data.Frame{table("analytics", "a", "b"), series, table("ml", "c")})
+	if len(frames) != 2 {
+		t.Fatalf("expected table and series, got %d frames", len(frames))
+	}
+
+	if rows, _ := frames[0].RowLen(); rows != 3 || frames[0].Fields[0].At(2).(string) != "ml" {
+		t.Errorf("expected merged table with 3 rows, got %d", rows)
+	}
+
+	if len(frames[0].Meta.Notices) != 2 {
+		t.Errorf("expected notices of both workspaces, got %d", len(frames[0].Meta.Notices))
+	}
+}
+
+func TestWorkspaceCaches(t *testing.T) {
+	t.Parallel()
+
+	d := &Datasource{runHistory: newRunHistoryCache(), metricsBuffer: newMetricsBuffer(servingMetricsRetention)}
+	entry := &workspaceEntry{runHistory: newRunHistoryCache(), metricsBuffer: newMetricsBuffer(servingMetricsRetention)}
+
+	if d.runHistoryOf(context.Background()) != d.runHistory || d.metricsBufferOf(context.Background()) != d.metricsBuffer {
+		t.Error("expected datasource caches without a workspace")
+	}
+
+	ctx := withWorkspace(context.Background(), entry)
+	if d.runHistoryOf(ctx) != entry.runHistory || d.metricsBufferOf(ctx) != entry.metricsBuffer {
+		t.Error("expected workspace caches in account mode")
+	}
+}
@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/google/go-querystring/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// valueVar is replaced by the value of the field a data link is attached to.
+const valueVar = "${__value.raw}"
+
+// fieldUnits maps the unit suffix of field names like "Run Duration (milliseconds)" to the
+// Grafana unit of the field.
+var fieldUnits = map[string]string{
+	"milliseconds": "ms",
+	"seconds":      "s",
+	"minutes":      "m",
+	"bytes":        "decbytes",
+	"%":            "percent",
+}
+
+// fieldVar is replaced by the value of another field in the same row.
+func fieldVar(name string) string {
+	return fmt.Sprintf(`${__data.fields["%s"]}`, name)
+}
+
+// workspaceLink returns a data link to a page of the workspace UI.
+func workspaceLink(host string, title string, path string) data.DataLink {
+	return data.DataLink{
+		Title:       title,
+		URL:         strings.TrimSuffix(host, "/") + path,
+		TargetBlank: true,
+	}
+}
+
+func jobLink(host string) data.DataLink {
+	return workspaceLink(host, "View job", "/jobs/"+valueVar)
+}
+
+// jobRunLink links a run ID field, using the "Job ID" field of the same frame.
+func jobRunLink(host string) data.DataLink {
+	return workspaceLink(host, "View run", "/jobs/"+fieldVar("Job ID")+"/runs/"+valueVar)
+}
+
+func pipelineLink(host string) data.DataLink {
+	return workspaceLink(host, "View pipeline", "/pipelines/"+valueVar)
+}
+
+// pipelineUpdateLink links an update ID field, pipelineId is either an id or a fieldVar.
+func pipelineUpdateLink(host string, pipelineId string) data.DataLink {
+	return workspaceLink(host, "View update", "/pipelines/"+pipelineId+"/updates/"+valueVar)
+}
+
+func clusterLink(host string) data.DataLink {
+	return data.DataLink{Title: "View cluster", URL: clusterURL(host, valueVar), TargetBlank: true}
+}
+
+func warehouseLink(host string) data.DataLink {
+	return workspaceLink(host, "View warehouse", "/sql/warehouses/"+valueVar)
+}
+
+func queryProfileLink(host string) data.DataLink {
+	return workspaceLink(host, "View query profile", "/sql/history?queryId="+valueVar)
+}
+
+func servingEndpointLink(host string) data.DataLink {
+	return workspaceLink(host, "View endpoint", "/ml/endpoints/"+valueVar)
+}
+
+func experimentLink(host string) data.DataLink {
+	return workspaceLink(host, "View experiment", "/ml/experiments/"+valueVar)
+}
+
+// mlflowRunLink links a run ID field, using the "Experiment ID" field of the same frame.
+func mlflowRunLink(host string) data.DataLink {
+	return workspaceLink(host, "View run", "/ml/experiments/"+fieldVar("Experiment ID")+"/runs/"+valueVar)
+}
+
+// tableLink links a full table name field to the table in Catalog Explorer.
+func tableLink(host string) data.DataLink {
+	return workspaceLink(host, "View table", "/explore/data/"+fieldVar("Catalog")+"/"+fieldVar("Schema")+"/"+fieldVar("Table Name"))
+}
+
+// withLinks adds data links to a field.
+func withLinks(field *data.Field, links ...data.DataLink) *data.Field {
+	if field.Config == nil {
+		field.Config = &data.FieldConfig{}
+	}
+
+	field.Config.Links = append(field.Config.Links, links...)
+	return field
+}
+
+// setFieldUnits sets the unit of fields named with a unit suffix, and a display name
+// without it. Labelled fields keep their name, which Grafana combines with the labels.
+func setFieldUnits(frame *data.Frame) {
+	for _, field := range frame.Fields {
+		name, suffix, ok := strings.Cut(field.Name, " (")
+		if !ok || !strings.HasSuffix(suffix, ")") {
+			continue
+		}
+
+		unit, ok := fieldUnits[strings.TrimSuffix(suffix, ")")]
+		if !ok {
+			continue
+		}
+
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+
+		field.Config.Unit = unit
+		if len(field.Labels) == 0 && field.Config.DisplayNameFromDS == "" {
+			field.Config.DisplayNameFromDS = name
+		}
+	}
+}
+
+// describeRequest formats an API request for the query inspector, as the query string of a
+// GET request, or the JSON body of any other request.
+func describeRequest(method string, path string, request any) string {
+	if request == nil {
+		return method + " " + path
+	}
+
+	if method == "GET" {
+		values, err := query.Values(request)
+		if err != nil || len(values) == 0 {
+			return method + " " + path
+		}
+
+		return method + " " + path + "?" + values.Encode()
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return method + " " + path
+	}
+
+	return method + " " + path + " " + string(body)
+}
+
+// describeStatement formats a statement and its parameters for the query inspector.
+func describeStatement(statement string, parameters []sql.StatementParameterListItem) string {
+	lines := []string{strings.TrimSpace(statement)}
+	for _, parameter := range parameters {
+		lines = append(lines, fmt.Sprintf("-- :%s = %s", parameter.Name, parameter.Value))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// withExecutedQuery sets the executed query of every frame of a response.
+func withExecutedQuery(frames []*data.Frame, executed ...string) []*data.Frame {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+
+		frame.Meta.ExecutedQueryString = strings.Join(executed, "\n")
+	}
+
+	return frames
+}
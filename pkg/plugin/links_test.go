@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestJobRunFrameLinks(t *testing.T) {
+	t.Parallel()
+
+	frame := buildJobRunFrame([]jobs.BaseRun{{JobId: 1, RunId: 2, Status: &jobs.RunStatus{}}}, testHost)
+
+	jobField, _ := frame.FieldByName("Job ID")
+	if links := jobField.Config.Links; len(links) != 1 || links[0].URL != "https://example.com/jobs/${__value.raw}" {
+		t.Errorf("unexpected job links: %+v", links)
+	}
+
+	runField, _ := frame.FieldByName("Run ID")
+	if links := runField.Config.Links; len(links) != 1 || links[0].URL != `https://example.com/jobs/${__data.fields["Job ID"]}/runs/${__value.raw}` {
+		t.Errorf("unexpected run links: %+v", links)
+	}
+}
+
+func TestSetFieldUnits(t *testing.T) {
+	t.Parallel()
+
+	frame := data.NewFrame("test",
+		data.NewField("Time", nil, []time.Time{}),
+		data.NewField("Run Duration (milliseconds)", nil, []int64{}),
+		data.NewField("P95 Duration (milliseconds)", data.Labels{"warehouse": "a"}, []float64{}),
+		data.NewField("Traffic (%)", nil, []int64{}),
+		data.NewField("Name (first)", nil, []string{}),
+	)
+	setFieldUnits(frame)
+
+	if config := frame.Fields[1].Config; config.Unit != "ms" || config.DisplayNameFromDS != "Run Duration" {
+		t.Errorf("unexpected duration config: %+v", config)
+	}
+
+	if config := frame.Fields[2].Config; config.Unit != "ms" || config.DisplayNameFromDS != "" {
+		t.Errorf("expected labelled field to keep its name, got %+v", config)
+	}
+
+	if config := frame.Fields[3].Config; config.Unit != "percent" {
+		t.Errorf("unexpected percent config: %+v", config)
+	}
+
+	if frame.Fields[0].Config != nil || frame.Fields[4].Config != nil {
+		t.Error("expected fields without a unit to be left alone")
+	}
+}
+
+func TestDescribeRequest(t *testing.T) {
+	t.Parallel()
+
+	got := describeListRuns(jobs.ListRunsRequest{JobId: 42, Limit: 25, ActiveOnly: true})
+	if want := "GET /api/2.2/jobs/runs/list?active_only=true&job_id=42&limit=25"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	got = describeRequest("POST", "/api/2.1/clusters/events", struct {
+		ClusterId string `json:"cluster_id"`
+	}{"abc"})
+	if want := `POST /api/2.1/clusters/events {"cluster_id":"abc"}`; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	got = describeStatement("\nSELECT * FROM t WHERE a = :a\n", []sql.StatementParameterListItem{{Name: "a", Value: "1"}})
+	if want := "SELECT * FROM t WHERE a = :a\n-- :a = 1"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	frames := withExecutedQuery([]*data.Frame{data.NewFrame("a")}, "first", "second")
+	if frames[0].Meta.ExecutedQueryString != "first\nsecond" {
+		t.Errorf("unexpected executed query: %q", frames[0].Meta.ExecutedQueryString)
+	}
+}
@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go"
+	"github.com/databricks/databricks-sdk-go/service/sql"
+)
+
+// statementResult holds the rows of a statement executed on a SQL warehouse, as returned
+// by the JSON_ARRAY format. NULL values are returned as empty strings.
+type statementResult struct {
+	Columns []sql.ColumnInfo
+	Rows    [][]string
+}
+
+// executeStatement runs a parameterized statement on the given warehouse, waits for it to
+// finish and collects all result chunks.
+func executeStatement(ctx context.Context, w *databricks.WorkspaceClient, warehouseId string, statement string, parameters []sql.StatementParameterListItem) (*statementResult, error) {
+	request := sql.ExecuteStatementRequest{
+		WarehouseId:   warehouseId,
+		Statement:     statement,
+		Parameters:    parameters,
+		Disposition:   sql.DispositionInline,
+		Format:        sql.FormatJsonArray,
+		WaitTimeout:   "30s",
+		OnWaitTimeout: sql.ExecuteStatementRequestOnWaitTimeoutContinue,
+	}
+
+	response, err := w.StatementExecution.ExecuteAndWait(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &statementResult{}
+	if response.Manifest != nil && response.Manifest.Schema != nil {
+		result.Columns = response.Manifest.Schema.Columns
+	}
+
+	chunk := response.Result
+	for chunk != nil {
+		result.Rows = append(result.Rows, chunk.DataArray...)
+		if chunk.NextChunkIndex == 0 {
+			break
+		}
+
+		chunk, err = w.StatementExecution.GetStatementResultChunkNByStatementIdAndChunkIndex(ctx, response.StatementId, chunk.NextChunkIndex)
+		if err != nil {
+			return nil, fmt.Errorf("fetch result chunk: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// columnIndex returns the position of the named column in the result, or -1.
+func (r *statementResult) columnIndex(name string) int {
+	return slices.IndexFunc(r.Columns, func(c sql.ColumnInfo) bool {
+		return c.Name == name
+	})
+}
+
+// value returns the named column of a row, or an empty string when it doesn't exist.
+func (r *statementResult) value(row []string, name string) string {
+	i := r.columnIndex(name)
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+
+	return row[i]
+}
+
+func stringParameter(name string, value string) sql.StatementParameterListItem {
+	return sql.StatementParameterListItem{Name: name, Value: value, Type: "STRING"}
+}
+
+func timestampParameter(name string, value time.Time) sql.StatementParameterListItem {
+	return sql.StatementParameterListItem{Name: name, Value: value.UTC().Format(time.RFC3339Nano), Type: "TIMESTAMP"}
+}
+
+// parseStatementTime parses TIMESTAMP and DATE values, returning nil for NULL.
+func parseStatementTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid timestamp: %s", value)
+}
+
+// parseStatementFloat parses numeric values, returning nil for NULL.
+func parseStatementFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// quoteIdentifier quotes a (possibly qualified) identifier such as catalog.schema.table,
+// escaping backticks in each part.
+func quoteIdentifier(parts ...string) string {
+	quoted := []string{}
+	for _, part := range parts {
+		quoted = append(quoted, "`"+strings.ReplaceAll(part, "`", "``")+"`")
+	}
+
+	return strings.Join(quoted, ".")
+}
@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/rayalex/databricks/pkg/models"
+)
+
+// streamBufferSize is the number of frames buffered per subscriber, frames are dropped for
+// subscribers that fall further behind.
+const streamBufferSize = 16
+
+// streamPollFunc polls the source of a stream until ctx is done. Whenever something changed
+// it publishes the changes, and a snapshot of the current state for new subscribers. It
+// returns an error when it can't poll at all, e.g. when the first poll fails.
+type streamPollFunc func(ctx context.Context, publish func(changes *data.Frame, snapshot *data.Frame)) error
+
+type streamSubscription struct {
+	updates <-chan *data.Frame
+	done    <-chan struct{}
+
+	// err returns the error the poller stopped with, once done is closed
+	err func() error
+}
+
+type streamPoller struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	subscribers map[int]chan *data.Frame
+	nextId      int
+
+	// snapshot is sent to new subscribers, so they don't wait for the next change
+	snapshot *data.Frame
+
+	// err is the error the poll function returned, if it stopped on its own
+	err error
+}
+
+// streamPollers runs one poller per channel path, shared by all of its subscribers. A poller
+// starts with the first subscriber and stops when the last one leaves, or when the
+// datasource instance is disposed.
+type streamPollers struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	pollers map[string]*streamPoller
+}
+
+func newStreamPollers(ctx context.Context) *streamPollers {
+	return &streamPollers{ctx: ctx, pollers: map[string]*streamPoller{}}
+}
+
+// subscribe subscribes to the poller of path, starting it with poll if it isn't running.
+// The returned function must be called to unsubscribe.
+func (s *streamPollers) subscribe(path string, poll streamPollFunc) (streamSubscription, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poller, ok := s.pollers[path]
+	if !ok {
+		ctx, cancel := context.WithCancel(s.ctx)
+		poller = &streamPoller{ctx: ctx, cancel: cancel, subscribers: map[int]chan *data.Frame{}}
+		s.pollers[path] = poller
+
+		go func() {
+			err := poll(ctx, func(changes *data.Frame, snapshot *data.Frame) {
+				s.publish(poller, changes, snapshot)
+			})
+			s.stop(path, poller, err)
+		}()
+	}
+
+	id := poller.nextId
+	poller.nextId++
+
+	updates := make(chan *data.Frame, streamBufferSize)
+	if poller.snapshot != nil {
+		updates <- poller.snapshot
+	}
+	poller.subscribers[id] = updates
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(poller.subscribers, id)
+		if len(poller.subscribers) == 0 {
+			poller.cancel()
+			if s.pollers[path] == poller {
+				delete(s.pollers, path)
+			}
+		}
+	}
+
+	err := func() error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return poller.err
+	}
+
+	return streamSubscription{updates: updates, done: poller.ctx.Done(), err: err}, unsubscribe
+}
+
+// stop is called once the poll function of a poller returned. Its subscribers are told to
+// stop with the error, and the next subscriber of path starts a new poller.
+func (s *streamPollers) stop(path string, poller *streamPoller, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if poller.ctx.Err() == nil {
+		poller.err = err
+	}
+
+	poller.cancel()
+	if s.pollers[path] == poller {
+		delete(s.pollers, path)
+	}
+}
+
+func (s *streamPollers) publish(poller *streamPoller, changes *data.Frame, snapshot *data.Frame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poller.snapshot = snapshot
+	for _, updates := range poller.subscribers {
+		select {
+		case updates <- changes:
+		default:
+		}
+	}
+}
+
+// streamChannel returns the Grafana Live channel of a stream path of this datasource.
+func (d *Datasource) streamChannel(path string) string {
+	return live.Channel{Scope: live.ScopeDatasource, Namespace: d.settings.UID, Path: path}.String()
+}
+
+// streamPollFunc returns the poller of a stream path, or an error when the path is invalid.
+func (d *Datasource) streamPollFunc(pCtx backend.PluginContext, path string) (streamPollFunc, error) {
+	prefix, _, _ := strings.Cut(path, "/")
+	switch prefix {
+	case jobRunStreamPrefix:
+		filter, err := parseJobRunStreamPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.pollJobRuns(pCtx, filter), nil
+	case pipelineStreamPrefix:
+		pipelineId, err := parsePipelineStreamPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.pollPipelineUpdates(pCtx, pipelineId), nil
+	default:
+		return nil, fmt.Errorf("unknown stream path: %s", path)
+	}
+}
+
+// SubscribeStream is called when a client wants to connect to a stream, it only checks that
+// the path is valid. Streams are bound to a single workspace, so there are none in account mode.
+func (d *Datasource) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if config, err := models.LoadPluginSettings(d.settings); err != nil || isAccountMode(config) {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+
+	if _, err := d.streamPollFunc(req.PluginContext, req.Path); err != nil {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+}
+
+// PublishStream is called when a client sends a message to a stream, which isn't supported.
+func (d *Datasource) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+// RunStream is called once per stream path while it has subscribers, and sends the frames
+// of the shared poller of that path until the stream or the datasource instance is closed.
+// When the poller fails, its error is returned.
+func (d *Datasource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	poll, err := d.streamPollFunc(req.PluginContext, req.Path)
+	if err != nil {
+		return err
+	}
+
+	subscription, unsubscribe := d.streams.subscribe(strings.TrimSuffix(req.Path, "/"), poll)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-subscription.done:
+			return subscription.err()
+		case frame := <-subscription.updates:
+			if err := sender.SendFrame(frame, data.IncludeAll); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// timelineIdle is the state of the gaps between spans of a timeline.
+	timelineIdle = "idle"
+
+	// timelineInstant is how long an instant span (ending when it starts) is shown, the
+	// resolution of the timestamps, so every timestamp has a single state.
+	timelineInstant = time.Millisecond
+)
+
+// timelineSpan is a period a job or pipeline spent in a state, End is nil while it lasts.
+type timelineSpan struct {
+	Start time.Time
+	End   *time.Time
+	State string
+}
+
+// timelineTransitions returns the state at every start and end of the spans, in the shape the
+// State Timeline panel expects. Gaps between spans, and the time from the start of the range
+// to the first span, are idle. Overlapping spans are shown by the one that started last while
+// it lasts, then by the earlier span again if it is still active. Instant spans are shown for
+// a single millisecond.
+func timelineTransitions(spans []timelineSpan, from time.Time) ([]time.Time, []string) {
+	spans = slices.Clone(spans)
+	for i, span := range spans {
+		if span.End != nil && !span.End.After(span.Start) {
+			end := span.Start.Add(timelineInstant)
+			spans[i].End = &end
+		}
+	}
+
+	slices.SortStableFunc(spans, func(i, j timelineSpan) int {
+		return i.Start.Compare(j.Start)
+	})
+
+	times := []time.Time{}
+	states := []string{}
+	emit := func(t time.Time, state string) {
+		times = append(times, t)
+		states = append(states, state)
+	}
+
+	if len(spans) > 0 && !from.IsZero() && from.Before(spans[0].Start) {
+		emit(from, timelineIdle)
+	}
+
+	boundaries := []time.Time{}
+	for _, span := range spans {
+		boundaries = append(boundaries, span.Start)
+		if span.End != nil {
+			boundaries = append(boundaries, *span.End)
+		}
+	}
+
+	slices.SortFunc(boundaries, time.Time.Compare)
+	boundaries = slices.CompactFunc(boundaries, time.Time.Equal)
+
+	for _, t := range boundaries {
+		state := timelineIdle
+		started := false
+		for _, span := range spans {
+			if span.Start.After(t) {
+				break
+			}
+
+			if span.Start.Equal(t) {
+				started = true
+			}
+
+			// spans are sorted by start, so the last active one started last
+			if span.End == nil || span.End.After(t) {
+				state = span.State
+			}
+		}
+
+		if started || len(states) == 0 || states[len(states)-1] != state {
+			emit(t, state)
+		}
+	}
+
+	return times, states
+}
+
+// buildTimelineFrame returns the timeline of one job or pipeline, with a single state field
+// shown with the given name.
+func buildTimelineFrame(name string, labels data.Labels, spans []timelineSpan, from time.Time) *data.Frame {
+	times, states := timelineTransitions(spans, from)
+
+	field := data.NewField("State", labels, states)
+	field.Config = &data.FieldConfig{DisplayNameFromDS: name}
+
+	return data.NewFrame(name,
+		data.NewField("Time", nil, times),
+		field,
+	)
+}
+
+// sortTimelineFrames sorts timeline frames by name, so the rows of the panel are stable.
+// Numbers within the names are compared by value, so "Job 2" comes before "Job 10".
+func sortTimelineFrames(frames []*data.Frame) []*data.Frame {
+	slices.SortStableFunc(frames, func(i, j *data.Frame) int {
+		return naturalCompare(i.Name, j.Name)
+	})
+
+	return frames
+}
+
+// naturalCompare compares two strings, treating runs of digits as numbers.
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da == "" || db == "" {
+			if c := cmp.Compare(a[0], b[0]); c != 0 {
+				return c
+			}
+
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// without leading zeros, the longer number is the larger one
+		na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if c := cmp.Or(cmp.Compare(len(na), len(nb)), cmp.Compare(na, nb)); c != 0 {
+			return c
+		}
+
+		a, b = a[len(da):], b[len(db):]
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
+
+// leadingDigits returns the digits at the start of s.
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return s[:i]
+}
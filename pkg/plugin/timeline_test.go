@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sdk-go/service/jobs"
+	"github.com/databricks/databricks-sdk-go/service/pipelines"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestTimelineTransitions(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return from.Add(time.Duration(minutes) * time.Minute)
+	}
+	end := func(minutes int) *time.Time {
+		t := at(minutes)
+		return &t
+	}
+
+	t.Run("should fill gaps with idle", func(t *testing.T) {
+		times, states := timelineTransitions([]timelineSpan{
+			{Start: at(30), End: end(40), State: "FAILED"},
+			{Start: at(10), End: end(20), State: "SUCCESS"},
+			// starts when the previous run ends, without an idle gap
+			{Start: at(40), End: end(45), State: "SUCCESS"},
+		}, from)
+
+		wantTimes := []time.Time{at(0), at(10), at(20), at(30), at(40), at(45)}
+		wantStates := []string{timelineIdle, "SUCCESS", timelineIdle, "FAILED", "SUCCESS", timelineIdle}
+		if !slices.EqualFunc(times, wantTimes, time.Time.Equal) || !slices.Equal(states, wantStates) {
+			t.Errorf("unexpected transitions: %v %v", times, states)
+		}
+	})
+
+	t.Run("should keep overlapping and running spans", func(t *testing.T) {
+		times, states := timelineTransitions([]timelineSpan{
+			{Start: at(0), End: end(30), State: "SUCCESS"},
+			// ends before the first run, which is shown again until it ends
+			{Start: at(10), End: end(20), State: "FAILED"},
+			{Start: at(40), State: "RUNNING"},
+			{Start: at(50), End: end(50), State: "CANCELED"},
+		}, from)
+
+		wantTimes := []time.Time{at(0), at(10), at(20), at(30), at(40), at(50), at(50).Add(time.Millisecond)}
+		wantStates := []string{"SUCCESS", "FAILED", "SUCCESS", timelineIdle, "RUNNING", "CANCELED", "RUNNING"}
+		if !slices.EqualFunc(times, wantTimes, time.Time.Equal) || !slices.Equal(states, wantStates) {
+			t.Errorf("unexpected transitions: %v %v", times, states)
+		}
+	})
+
+	t.Run("should emit a single state per timestamp", func(t *testing.T) {
+		times, _ := timelineTransitions([]timelineSpan{
+			{Start: at(10), End: end(10), State: "SKIPPED"},
+			{Start: at(20), End: end(30), State: "SUCCESS"},
+			{Start: at(30), End: end(30), State: "CANCELED"},
+		}, from)
+
+		if len(slices.CompactFunc(slices.Clone(times), time.Time.Equal)) != len(times) {
+			t.Errorf("expected distinct timestamps, got %v", times)
+		}
+	})
+
+	t.Run("should return no transitions without spans", func(t *testing.T) {
+		if times, _ := timelineTransitions(nil, from); len(times) != 0 {
+			t.Errorf("expected no transitions, got %v", times)
+		}
+	})
+}
+
+func TestJobRunTimelineFrames(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(d time.Duration) int64 {
+		return from.Add(d).UnixMilli()
+	}
+
+	runs := []jobs.BaseRun{
+		{JobId: 2, RunName: "load", StartTime: ms(time.Hour), State: &jobs.RunState{LifeCycleState: jobs.RunLifeCycleStateRunning}},
+		{JobId: 1, RunName: "extract", StartTime: ms(time.Minute), EndTime: ms(2 * time.Minute), State: &jobs.RunState{ResultState: jobs.RunResultStateSuccess}},
+	}
+
+	frames := buildJobRunTimelineFrames(runs, from)
+	if len(frames) != 2 || frames[0].Name != "Job 1" || frames[1].Name != "Job 2" {
+		t.Fatalf("expected one frame per job named by job id, got %d", len(frames))
+	}
+
+	if frames[0].Fields[1].Labels["job_id"] != "1" || frames[0].Fields[1].Config.DisplayNameFromDS != "Job 1" {
+		t.Errorf("unexpected state field: %+v", frames[0].Fields[1])
+	}
+
+	if rows, _ := frames[0].RowLen(); rows != 3 {
+		t.Errorf("expected idle, success and idle, got %d rows", rows)
+	}
+
+	if state := frames[1].Fields[1].At(1).(string); state != "RUNNING" {
+		t.Errorf("expected running state, got %s", state)
+	}
+}
+
+func TestSortTimelineFrames(t *testing.T) {
+	t.Parallel()
+
+	frames := []*data.Frame{data.NewFrame("Job 10"), data.NewFrame("Job 2"), data.NewFrame("Job 1"), data.NewFrame("ETL")}
+	names := []string{}
+	for _, frame := range sortTimelineFrames(frames) {
+		names = append(names, frame.Name)
+	}
+
+	if !slices.Equal(names, []string{"ETL", "Job 1", "Job 2", "Job 10"}) {
+		t.Errorf("unexpected order: %v", names)
+	}
+}
+
+func TestPipelineUpdateSpans(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	updates := []pipelines.UpdateInfo{
+		{UpdateId: "u3", CreationTime: start.Add(2 * time.Hour).UnixMilli(), State: pipelines.UpdateInfoStateRunning},
+		{UpdateId: "u2", CreationTime: start.Add(time.Hour).UnixMilli(), State: pipelines.UpdateInfoStateCanceled},
+		{UpdateId: "u1", CreationTime: start.UnixMilli(), State: pipelines.UpdateInfoStateCompleted},
+	}
+	events := []pipelineEvent{
+		{EventType: pipelineEventUpdateProgress, Timestamp: "2025-01-01T10:00:05.000Z", Origin: &pipelines.Origin{UpdateId: "u1"}, Details: pipelineEventDetails{UpdateProgress: &updateProgressDetails{State: "RUNNING"}}},
+		{EventType: pipelineEventUpdateProgress, Timestamp: "2025-01-01T10:20:00.000Z", Origin: &pipelines.Origin{UpdateId: "u1"}, Details: pipelineEventDetails{UpdateProgress: &updateProgressDetails{State: "COMPLETED"}}},
+	}
+
+	spans, missing := pipelineUpdateSpans(updates, events)
+	if len(spans) != 3 || missing != 1 {
+		t.Fatalf("expected 3 spans with 1 missing end, got %d and %d", len(spans), missing)
+	}
+
+	if spans[0].End != nil {
+		t.Error("expected running update without end")
+	}
+
+	if !spans[1].End.Equal(spans[1].Start) {
+		t.Error("expected update without end event to be instant")
+	}
+
+	if !spans[2].End.Equal(start.Add(20*time.Minute)) || spans[2].State != "COMPLETED" {
+		t.Errorf("unexpected completed span: %+v", spans[2])
+	}
+}
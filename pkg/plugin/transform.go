@@ -0,0 +1,499 @@
+package plugin
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	filterOpEqual        = "eq"
+	filterOpNotEqual     = "neq"
+	filterOpIn           = "in"
+	filterOpNotIn        = "not_in"
+	filterOpRegex        = "regex"
+	filterOpNotRegex     = "not_regex"
+	filterOpGreater      = "gt"
+	filterOpGreaterEqual = "gte"
+	filterOpLess         = "lt"
+	filterOpLessEqual    = "lte"
+
+	aggregationCount = "count"
+	aggregationSum   = "sum"
+	aggregationAvg   = "avg"
+	aggregationMin   = "min"
+	aggregationMax   = "max"
+)
+
+// queryTransform is applied to the frames of a query after fetching, in order: filters,
+// grouping, sorting and the top N limit.
+type queryTransform struct {
+	Filters      []fieldFilter      `json:"filters,omitempty"`
+	GroupBy      string             `json:"groupBy,omitempty"`
+	Aggregations []fieldAggregation `json:"aggregations,omitempty"`
+	SortBy       string             `json:"sortBy,omitempty"`
+	SortDesc     bool               `json:"sortDesc,omitempty"`
+	TopN         int                `json:"topN,omitempty"`
+}
+
+// fieldFilter keeps the rows whose field matches. Value is used by the comparison and regex
+// operators, Values by in and not_in.
+type fieldFilter struct {
+	Field  string   `json:"field"`
+	Op     string   `json:"op"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// fieldAggregation is computed per group; count counts the rows and needs no field.
+type fieldAggregation struct {
+	Field string `json:"field,omitempty"`
+	Func  string `json:"func"`
+}
+
+// validate checks the transform before the query runs, and compiles the filter patterns.
+func (t *queryTransform) validate() error {
+	for i := range t.Filters {
+		filter := &t.Filters[i]
+		if filter.Field == "" {
+			return fmt.Errorf("filter field is required")
+		}
+
+		switch filter.Op {
+		case filterOpEqual, filterOpNotEqual, filterOpIn, filterOpNotIn:
+		case filterOpGreater, filterOpGreaterEqual, filterOpLess, filterOpLessEqual:
+		case filterOpRegex, filterOpNotRegex:
+			pattern, err := regexp.Compile(filter.Value)
+			if err != nil {
+				return fmt.Errorf("invalid regex for field %s: %v", filter.Field, err)
+			}
+			filter.pattern = pattern
+		default:
+			return fmt.Errorf("unknown filter operator: %s", filter.Op)
+		}
+	}
+
+	for _, aggregation := range t.Aggregations {
+		switch aggregation.Func {
+		case aggregationCount:
+		case aggregationSum, aggregationAvg, aggregationMin, aggregationMax:
+			if aggregation.Field == "" {
+				return fmt.Errorf("%s aggregation field is required", aggregation.Func)
+			}
+		default:
+			return fmt.Errorf("unknown aggregation: %s", aggregation.Func)
+		}
+	}
+
+	if len(t.Aggregations) > 0 && t.GroupBy == "" {
+		return fmt.Errorf("aggregations require a group by field")
+	}
+
+	if t.TopN < 0 {
+		return fmt.Errorf("top N must not be negative")
+	}
+
+	return nil
+}
+
+// apply transforms every frame of a response on its own. Frames without the fields the
+// transform refers to (e.g. the notice frame of a failed workspace) are passed through, it is
+// only an error when none of the frames has them.
+func (t *queryTransform) apply(frames []*data.Frame) ([]*data.Frame, error) {
+	transformed := make([]*data.Frame, 0, len(frames))
+	applied := 0
+	var missing error
+	for _, frame := range frames {
+		if err := t.checkFields(frame); err != nil {
+			missing = err
+			transformed = append(transformed, frame)
+			continue
+		}
+
+		frame, err := t.applyFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+
+		transformed = append(transformed, frame)
+		applied++
+	}
+
+	if applied == 0 && missing != nil {
+		return nil, missing
+	}
+
+	return transformed, nil
+}
+
+// checkFields returns an error if the frame lacks a field the filters or grouping refer to,
+// or the sort field when the frame isn't grouped.
+func (t *queryTransform) checkFields(frame *data.Frame) error {
+	names := []string{}
+	for _, filter := range t.Filters {
+		names = append(names, filter.Field)
+	}
+
+	if t.GroupBy != "" {
+		names = append(names, t.GroupBy)
+		for _, aggregation := range t.Aggregations {
+			if aggregation.Field != "" {
+				names = append(names, aggregation.Field)
+			}
+		}
+	} else if t.SortBy != "" {
+		names = append(names, t.SortBy)
+	}
+
+	for _, name := range names {
+		if _, err := transformField(frame, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *queryTransform) applyFrame(frame *data.Frame) (*data.Frame, error) {
+	rows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []int{}
+	for row := range rows {
+		selected = append(selected, row)
+	}
+
+	for _, filter := range t.Filters {
+		field, err := transformField(frame, filter.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		selected = slices.DeleteFunc(selected, func(row int) bool {
+			return !filter.matches(field, row)
+		})
+	}
+
+	if t.GroupBy != "" {
+		frame, err = t.group(frame, selected)
+		if err != nil {
+			return nil, err
+		}
+
+		selected = selected[:0]
+		for row := range frame.Rows() {
+			selected = append(selected, row)
+		}
+	}
+
+	if t.SortBy != "" {
+		field, err := transformField(frame, t.SortBy)
+		if err != nil {
+			return nil, err
+		}
+
+		slices.SortStableFunc(selected, func(i, j int) int {
+			return compareFieldRows(field, i, j, t.SortDesc)
+		})
+	}
+
+	if t.TopN > 0 && len(selected) > t.TopN {
+		selected = selected[:t.TopN]
+	}
+
+	return selectRows(frame, selected), nil
+}
+
+// group returns a frame with a row per distinct value of the group by field, in order of
+// first appearance, and a column per aggregation. Without aggregations, the rows are counted.
+func (t *queryTransform) group(frame *data.Frame, rows []int) (*data.Frame, error) {
+	groupField, err := transformField(frame, t.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregations := t.Aggregations
+	if len(aggregations) == 0 {
+		aggregations = []fieldAggregation{{Func: aggregationCount}}
+	}
+
+	type groupKey struct {
+		value string
+		null  bool
+	}
+
+	keys := []groupKey{}
+	groups := map[groupKey][]int{}
+	for _, row := range rows {
+		value, ok := fieldValueString(groupField, row)
+		key := groupKey{value, !ok}
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	// the group field keeps the type of the source field, so numeric keys sort numerically
+	keyField := data.NewFieldFromFieldType(groupField.Type(), len(keys))
+	keyField.Name = groupField.Name
+	keyField.Labels = groupField.Labels
+	keyField.Config = groupField.Config
+	for i, key := range keys {
+		keyField.Set(i, groupField.CopyAt(groups[key][0]))
+	}
+
+	grouped := data.NewFrame(frame.Name, keyField)
+
+	// a grouped frame is a table, whatever the type of the source frame
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		meta.Type = ""
+		meta.TypeVersion = data.FrameTypeVersion{}
+		grouped.Meta = &meta
+	}
+
+	for _, aggregation := range aggregations {
+		if aggregation.Func == aggregationCount {
+			counts := []int64{}
+			for _, key := range keys {
+				counts = append(counts, int64(len(groups[key])))
+			}
+
+			grouped.Fields = append(grouped.Fields, data.NewField("Count", nil, counts))
+			continue
+		}
+
+		field, err := transformField(frame, aggregation.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		if !field.Type().Numeric() {
+			return nil, fmt.Errorf("can't aggregate non-numeric field %s", field.Name)
+		}
+
+		results := []*float64{}
+		for _, key := range keys {
+			results = append(results, aggregate(field, groups[key], aggregation.Func))
+		}
+
+		name := strings.ToUpper(aggregation.Func[:1]) + aggregation.Func[1:] + " " + field.Name
+		grouped.Fields = append(grouped.Fields, data.NewField(name, field.Labels, results))
+	}
+
+	return grouped, nil
+}
+
+// aggregate returns the aggregate of the non-null values of a numeric field, or nil if all
+// values are null.
+func aggregate(field *data.Field, rows []int, fn string) *float64 {
+	values := []float64{}
+	for _, row := range rows {
+		value, err := field.NullableFloatAt(row)
+		if err != nil || value == nil {
+			continue
+		}
+
+		values = append(values, *value)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	var result float64
+	switch fn {
+	case aggregationMin:
+		result = slices.Min(values)
+	case aggregationMax:
+		result = slices.Max(values)
+	default:
+		for _, value := range values {
+			result += value
+		}
+
+		if fn == aggregationAvg {
+			result /= float64(len(values))
+		}
+	}
+
+	return &result
+}
+
+// matches reports whether the field value of a row passes the filter. Null values only pass
+// the negated operators.
+func (f fieldFilter) matches(field *data.Field, row int) bool {
+	value, ok := fieldValueString(field, row)
+
+	switch f.Op {
+	case filterOpEqual:
+		return ok && value == f.Value
+	case filterOpNotEqual:
+		return !ok || value != f.Value
+	case filterOpIn:
+		return ok && slices.Contains(f.Values, value)
+	case filterOpNotIn:
+		return !ok || !slices.Contains(f.Values, value)
+	case filterOpRegex:
+		return ok && f.pattern.MatchString(value)
+	case filterOpNotRegex:
+		return !ok || !f.pattern.MatchString(value)
+	}
+
+	if !ok {
+		return false
+	}
+
+	result, ok := compareFieldValue(field, row, f.Value)
+	if !ok {
+		return false
+	}
+
+	switch f.Op {
+	case filterOpGreater:
+		return result > 0
+	case filterOpGreaterEqual:
+		return result >= 0
+	case filterOpLess:
+		return result < 0
+	case filterOpLessEqual:
+		return result <= 0
+	default:
+		return false
+	}
+}
+
+// compareFieldValue compares a non-null field value to a filter value: numerically for
+// numeric fields, as RFC 3339 time for time fields, and as strings otherwise.
+func compareFieldValue(field *data.Field, row int, value string) (int, bool) {
+	switch {
+	case field.Type().Numeric():
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		f, err := field.NullableFloatAt(row)
+		if err != nil || f == nil {
+			return 0, false
+		}
+
+		return cmp.Compare(*f, v), true
+	case field.Type().Time():
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return 0, false
+		}
+
+		t, ok := fieldTimeAt(field, row)
+		if !ok {
+			return 0, false
+		}
+
+		return t.Compare(v), true
+	default:
+		s, _ := fieldValueString(field, row)
+		return strings.Compare(s, value), true
+	}
+}
+
+// compareFieldRows orders two rows by a field, keeping null values last in both directions.
+func compareFieldRows(field *data.Field, i int, j int, desc bool) int {
+	iNull, jNull := field.NilAt(i), field.NilAt(j)
+	switch {
+	case iNull && jNull:
+		return 0
+	case iNull:
+		return 1
+	case jNull:
+		return -1
+	}
+
+	var result int
+	switch {
+	case field.Type().Numeric():
+		a, _ := field.FloatAt(i)
+		b, _ := field.FloatAt(j)
+		result = cmp.Compare(a, b)
+	case field.Type().Time():
+		a, _ := fieldTimeAt(field, i)
+		b, _ := fieldTimeAt(field, j)
+		result = a.Compare(b)
+	default:
+		a, _ := fieldValueString(field, i)
+		b, _ := fieldValueString(field, j)
+		result = strings.Compare(a, b)
+	}
+
+	if desc {
+		return -result
+	}
+
+	return result
+}
+
+// fieldValueString returns the value of a row formatted as a string, or false if it is null.
+func fieldValueString(field *data.Field, row int) (string, bool) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+func fieldTimeAt(field *data.Field, row int) (time.Time, bool) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, ok := value.(time.Time)
+	return t, ok
+}
+
+// transformField returns the field a transform refers to. Names match case-insensitively,
+// with or without their unit suffix, so "run duration" matches "Run Duration (milliseconds)".
+func transformField(frame *data.Frame, name string) (*data.Field, error) {
+	for _, field := range frame.Fields {
+		base, _, _ := strings.Cut(field.Name, " (")
+		if strings.EqualFold(field.Name, name) || strings.EqualFold(base, name) {
+			return field, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown field: %s", name)
+}
+
+// selectRows returns a copy of a frame with the given rows, in the given order, keeping the
+// field configs and frame metadata.
+func selectRows(frame *data.Frame, rows []int) *data.Frame {
+	selected := frame.EmptyCopy()
+	selected.Meta = frame.Meta
+	for i, field := range frame.Fields {
+		selected.Fields[i].Config = field.Config
+	}
+
+	for _, row := range rows {
+		selected.AppendRow(frame.RowCopy(row)...)
+	}
+
+	return selected
+}
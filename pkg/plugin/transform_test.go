@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func testTransformFrame() *data.Frame {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	duration := func(ms int64) *int64 {
+		return &ms
+	}
+
+	frame := data.NewFrame("Databricks Job Runs",
+		data.NewField("Start Time", nil, []time.Time{start, start.Add(time.Hour), start.Add(2 * time.Hour), start.Add(3 * time.Hour)}),
+		withLinks(data.NewField("Job ID", nil, []int64{1, 1, 2, 3}), jobLink(testHost)),
+		data.NewField("Run Name", nil, []string{"nightly etl", "nightly etl", "hourly sync", "backfill"}),
+		data.NewField("Result State", nil, []*string{optionalString("FAILED"), optionalString("SUCCESS"), optionalString("TIMEDOUT"), nil}),
+		data.NewField("Run Duration (milliseconds)", nil, []*int64{duration(100), duration(300), duration(50), nil}),
+	)
+	frame.AppendNotices(data.Notice{Text: "notice"})
+
+	return frame
+}
+
+func parseTestTransform(t *testing.T, raw string) *queryTransform {
+	t.Helper()
+
+	var transform queryTransform
+	if err := json.Unmarshal([]byte(raw), &transform); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := transform.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &transform
+}
+
+func TestQueryTransformFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should keep rows in the given states", func(t *testing.T) {
+		transform := parseTestTransform(t, `{"filters": [{"field": "result state", "op": "in", "values": ["FAILED", "TIMEDOUT"]}]}`)
+
+		frames, err := transform.apply([]*data.Frame{testTransformFrame()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frame := frames[0]
+		if frame.Rows() != 2 || frame.Fields[2].At(1).(string) != "hourly sync" {
+			t.Errorf("unexpected rows: %d", frame.Rows())
+		}
+
+		if len(frame.Fields[1].Config.Links) != 1 || len(frame.Meta.Notices) != 1 {
+			t.Error("expected links and notices to be kept")
+		}
+	})
+
+	t.Run("should combine regex and comparison filters", func(t *testing.T) {
+		transform := parseTestTransform(t, `{"filters": [
+			{"field": "Run Name", "op": "regex", "value": "^nightly"},
+			{"field": "Run Duration", "op": "gt", "value": "200"}
+		]}`)
+
+		frames, err := transform.apply([]*data.Frame{testTransformFrame()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frames[0].Rows() != 1 || *frames[0].Fields[3].At(0).(*string) != "SUCCESS" {
+			t.Errorf("unexpected rows: %d", frames[0].Rows())
+		}
+	})
+
+	t.Run("should keep null values for negated filters only", func(t *testing.T) {
+		transform := parseTestTransform(t, `{"filters": [{"field": "Result State", "op": "neq", "value": "SUCCESS"}]}`)
+
+		frames, err := transform.apply([]*data.Frame{testTransformFrame()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frames[0].Rows() != 3 {
+			t.Errorf("expected 3 rows, got %d", frames[0].Rows())
+		}
+	})
+
+	t.Run("should fail on unknown fields", func(t *testing.T) {
+		transform := parseTestTransform(t, `{"filters": [{"field": "Owner", "op": "eq", "value": "me"}]}`)
+
+		if _, err := transform.apply([]*data.Frame{testTransformFrame()}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("should pass through frames without the field", func(t *testing.T) {
+		transform := parseTestTransform(t, `{"filters": [{"field": "Result State", "op": "eq", "value": "SUCCESS"}]}`)
+
+		other := data.NewFrame("Databricks Job Run Logs", data.NewField("body", nil, []string{"done"}))
+		frames, err := transform.apply([]*data.Frame{testTransformFrame(), other})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(frames) != 2 || frames[0].Rows() != 1 || frames[1] != other {
+			t.Errorf("expected the filtered frame and the untouched one")
+		}
+	})
+}
+
+func TestQueryTransformSortAndTopN(t *testing.T) {
+	t.Parallel()
+
+	transform := parseTestTransform(t, `{"sortBy": "Run Duration (milliseconds)", "sortDesc": true, "topN": 3}`)
+
+	frames, err := transform.apply([]*data.Frame{testTransformFrame()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field, _ := frames[0].FieldByName("Run Duration (milliseconds)")
+	want := []int64{300, 100, 50}
+	if field.Len() != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), field.Len())
+	}
+
+	for i, w := range want {
+		if got := *field.At(i).(*int64); got != w {
+			t.Errorf("row %d: expected %d, got %d", i, w, got)
+		}
+	}
+}
+
+func TestQueryTransformGroup(t *testing.T) {
+	t.Parallel()
+
+	transform := parseTestTransform(t, `{
+		"groupBy": "Job ID",
+		"aggregations": [{"func": "count"}, {"func": "avg", "field": "Run Duration"}, {"func": "max", "field": "Run Duration"}],
+		"sortBy": "Count",
+		"sortDesc": true
+	}`)
+
+	frames, err := transform.apply([]*data.Frame{testTransformFrame()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := frames[0]
+	if len(frame.Fields) != 4 || frame.Fields[2].Name != "Avg Run Duration (milliseconds)" {
+		t.Fatalf("unexpected fields: %d", len(frame.Fields))
+	}
+
+	if frame.Rows() != 3 || frame.Fields[0].At(0).(int64) != 1 || frame.Fields[1].At(0).(int64) != 2 {
+		t.Errorf("unexpected first group")
+	}
+
+	if len(frame.Fields[0].Config.Links) != 1 {
+		t.Error("expected the links of the group field to be kept")
+	}
+
+	if avg := *frame.Fields[2].At(0).(*float64); avg != 200 {
+		t.Errorf("expected average 200, got %v", avg)
+	}
+
+	if frame.Fields[3].At(2).(*float64) != nil {
+		t.Error("expected null aggregate for a group without values")
+	}
+
+	t.Run("should sort numeric keys numerically and return a table", func(t *testing.T) {
+		series := data.NewFrame("Databricks Usage",
+			data.NewField("Workspace ID", nil, []int64{10, 9, 10}),
+			data.NewField("DBUs", nil, []float64{1, 2, 3}),
+		)
+		series.SetMeta(&data.FrameMeta{Type: data.FrameTypeNumericMulti, Notices: []data.Notice{{Text: "notice"}}})
+
+		transform := parseTestTransform(t, `{"groupBy": "Workspace ID", "aggregations": [{"func": "sum", "field": "DBUs"}], "sortBy": "Workspace ID"}`)
+		frames, err := transform.apply([]*data.Frame{series})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frames[0].Fields[0].At(0).(int64) != 9 || *frames[0].Fields[1].At(1).(*float64) != 4 {
+			t.Errorf("expected workspace 9 first, got %v", frames[0].Fields[0].At(0))
+		}
+
+		if frames[0].Meta.Type != "" || len(frames[0].Meta.Notices) != 1 || series.Meta.Type != data.FrameTypeNumericMulti {
+			t.Errorf("unexpected meta: %+v", frames[0].Meta)
+		}
+	})
+}
+
+func TestQueryTransformValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{
+		`{"filters": [{"field": "Run Name", "op": "like", "value": "a"}]}`,
+		`{"filters": [{"field": "Run Name", "op": "regex", "value": "("}]}`,
+		`{"aggregations": [{"func": "sum", "field": "Run Duration"}]}`,
+		`{"groupBy": "Job ID", "aggregations": [{"func": "avg"}]}`,
+		`{"topN": -1}`,
+	} {
+		var transform queryTransform
+		if err := json.Unmarshal([]byte(raw), &transform); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := transform.validate(); err == nil {
+			t.Errorf("expected %s to be invalid", raw)
+		}
+	}
+}
@@ -2,11 +2,22 @@ package plugin
 
 import (
 	"context"
+	"math"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/databricks/databricks-sdk-go/listing"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 func fetchWithLimit[T any](ctx context.Context, it listing.Iterator[T], maxItems int) ([]T, error) {
+	return fetchMatching(ctx, it, maxItems, func(T) bool { return true })
+}
+
+// fetchMatching is fetchWithLimit for the items the filter keeps, so the limit applies to the
+// matching items instead of every listed one.
+func fetchMatching[T any](ctx context.Context, it listing.Iterator[T], maxItems int, keep func(T) bool) ([]T, error) {
 	var result = []T{}
 
 	for it.HasNext(ctx) && len(result) < maxItems {
@@ -15,8 +26,112 @@ func fetchWithLimit[T any](ctx context.Context, it listing.Iterator[T], maxItems
 			return nil, err
 		}
 
-		result = append(result, item)
+		if keep(item) {
+			result = append(result, item)
+		}
 	}
 
 	return result, nil
 }
+
+// optionalUnixMilli converts an epoch timestamp in milliseconds to a nullable time,
+// treating zero as "not set".
+func optionalUnixMilli(ms int64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+
+	t := time.UnixMilli(ms)
+	return &t
+}
+
+// optionalString converts a string to a nullable string, treating empty as "not set".
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// optionalInt64 returns a pointer to the given value, for use in nullable fields.
+func optionalInt64(v int64) *int64 {
+	return &v
+}
+
+// minSampleInterval keeps tiny time ranges from producing an endless number of samples.
+const minSampleInterval = time.Millisecond
+
+// sampleInterval returns the spacing between samples of the query time range, based on
+// the query interval but never producing more than MaxDataPoints samples.
+func sampleInterval(query backend.DataQuery) time.Duration {
+	from, to := query.TimeRange.From, query.TimeRange.To
+
+	step := query.Interval
+	if query.MaxDataPoints > 0 {
+		step = max(step, to.Sub(from)/time.Duration(query.MaxDataPoints))
+	}
+
+	if step <= 0 {
+		step = to.Sub(from) / 100
+	}
+
+	return max(step, minSampleInterval)
+}
+
+// sampleTimes returns evenly spaced points across the query time range, spaced by
+// sampleInterval. The first point is the start of the bucket containing the start of the
+// range, so everything within the range falls into a bucket.
+func sampleTimes(query backend.DataQuery) []time.Time {
+	from, to := query.TimeRange.From, query.TimeRange.To
+	if from.IsZero() || to.IsZero() || !to.After(from) {
+		return []time.Time{}
+	}
+
+	step := sampleInterval(query)
+
+	times := []time.Time{}
+	for t := from.Truncate(step); !t.After(to); t = t.Add(step) {
+		times = append(times, t)
+	}
+
+	return times
+}
+
+// bucketIndex returns the index of the bucket in times (sorted bucket start times) that
+// contains t, or -1 when t is before the first bucket.
+func bucketIndex(times []time.Time, t time.Time) int {
+	return sort.Search(len(times), func(i int) bool {
+		return times[i].After(t)
+	}) - 1
+}
+
+// percentile returns the nearest-rank percentile (0-100) of the given values.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// median returns the median of the given values, averaging the middle two for an even count.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
@@ -0,0 +1,72 @@
+import React from 'react';
+import { InlineField, MultiSelect } from '@grafana/ui';
+import { SelectableValue } from '@grafana/data';
+import { ClusterQueryParams, MyQuery } from '../types';
+
+const toOptions = (values: string[]): Array<SelectableValue<string>> =>
+  values.map((value) => ({ label: value, value }));
+
+const stateOptions = toOptions([
+  'PENDING',
+  'RUNNING',
+  'RESTARTING',
+  'RESIZING',
+  'TERMINATING',
+  'TERMINATED',
+  'ERROR',
+  'UNKNOWN',
+]);
+
+const sourceOptions = toOptions(['UI', 'API', 'JOB', 'MODELS', 'PIPELINE', 'PIPELINE_MAINTENANCE', 'SQL']);
+
+interface ClustersEditorProps {
+  resourceParams: ClusterQueryParams;
+  onChange: (queryUpdate: Partial<MyQuery>) => void;
+  onRunQuery: () => void;
+}
+
+export default function ClustersEditor({ resourceParams, onChange, onRunQuery }: ClustersEditorProps) {
+  const onStatesChange = (values: Array<SelectableValue<string>>) => {
+    onChange({
+      resourceParams: {
+        ...resourceParams,
+        states: values.map((v) => v.value!),
+      },
+    });
+    onRunQuery();
+  };
+
+  const onClusterSourcesChange = (values: Array<SelectableValue<string>>) => {
+    onChange({
+      resourceParams: {
+        ...resourceParams,
+        clusterSources: values.map((v) => v.value!),
+      },
+    });
+    onRunQuery();
+  };
+
+  return (
+    <>
+      <InlineField label="States" tooltip="Filter clusters by state" labelWidth={14}>
+        <MultiSelect
+          options={stateOptions}
+          value={resourceParams.states || []}
+          onChange={onStatesChange}
+          placeholder="All"
+          width={40}
+        />
+      </InlineField>
+
+      <InlineField label="Cluster Sources" tooltip="Filter clusters by how they were created" labelWidth={16}>
+        <MultiSelect
+          options={sourceOptions}
+          value={resourceParams.clusterSources || []}
+          onChange={onClusterSourcesChange}
+          placeholder="All"
+          width={40}
+        />
+      </InlineField>
+    </>
+  );
+}
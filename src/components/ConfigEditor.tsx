@@ -19,6 +19,36 @@ export function ConfigEditor(props: Props) {
     });
   };
 
+  const onWarehouseIdChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        warehouseId: event.target.value,
+      },
+    });
+  };
+
+  const onAccountIdChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        accountId: event.target.value,
+      },
+    });
+  };
+
+  const onAccountHostChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        accountHost: event.target.value,
+      },
+    });
+  };
+
   const onClientIdChange = (event: ChangeEvent<HTMLInputElement>) => {
     onOptionsChange({
       ...options,
@@ -79,6 +109,46 @@ export function ConfigEditor(props: Props) {
           autoComplete="off"
         />
       </InlineField>
+      <InlineField
+        label="SQL Warehouse ID"
+        labelWidth={20}
+        interactive
+        tooltip={'SQL warehouse used to query system tables, e.g. billing usage'}
+      >
+        <Input
+          id="config-editor-warehouse-id"
+          onChange={onWarehouseIdChange}
+          value={jsonData.warehouseId}
+          placeholder="Enter the SQL warehouse ID (optional)"
+          width={40}
+          autoComplete="off"
+        />
+      </InlineField>
+      <InlineField
+        label="Account ID"
+        labelWidth={20}
+        interactive
+        tooltip={'Databricks account ID, queries fan out to the workspaces of the account when set'}
+      >
+        <Input
+          id="config-editor-account-id"
+          onChange={onAccountIdChange}
+          value={jsonData.accountId}
+          placeholder="Enter the account ID (optional)"
+          width={40}
+          autoComplete="off"
+        />
+      </InlineField>
+      <InlineField label="Account Console URL" labelWidth={20} interactive tooltip={'Account console URL'}>
+        <Input
+          id="config-editor-account-host"
+          onChange={onAccountHostChange}
+          value={jsonData.accountHost}
+          placeholder="https://accounts.cloud.databricks.com"
+          width={40}
+          autoComplete="off"
+        />
+      </InlineField>
       <InlineField label="Client ID" labelWidth={20} interactive tooltip={'Service principal Client ID'}>
         <SecretInput
           required
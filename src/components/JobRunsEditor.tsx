@@ -39,6 +39,15 @@ export function JobRunsEditor({
     });
   };
 
+  const onIncludeCostChange = (event: React.ChangeEvent<HTMLInputElement>) => {
+    onChange({
+      resourceParams: {
+        ...resourceParams,
+        includeCost: event.target.checked,
+      },
+    });
+  };
+
   const onRunTypeChange = (value: SelectableValue<string>) => {
     onChange({
       resourceParams: {
@@ -48,8 +57,59 @@ export function JobRunsEditor({
     });
   };
 
+  const onModeChange = (value: SelectableValue<string>) => {
+    onChange({
+      resourceParams: {
+        ...resourceParams,
+        mode: value?.value as JobRunQueryParams['mode'],
+      },
+    });
+    onRunQuery();
+  };
+
+  const onFormatChange = (value: SelectableValue<string>) => {
+    onChange({
+      resourceParams: {
+        ...resourceParams,
+        format: value?.value as JobRunQueryParams['format'],
+      },
+    });
+    onRunQuery();
+  };
+
   return (
     <>
+      <Stack direction="row" gap={0}>
+        <InlineField label="Mode" tooltip="Runs, or a per-job analysis of the runs" labelWidth={10}>
+          <Select
+            options={[
+              { label: 'Runs', value: 'runs' },
+              { label: 'Schedule Forecast', value: 'schedule' },
+              { label: 'SLA Evaluation', value: 'sla' },
+              { label: 'Flakiness', value: 'flakiness' },
+              { label: 'Reliability', value: 'reliability' },
+            ]}
+            value={resourceParams.mode || 'runs'}
+            onChange={onModeChange}
+            width={24}
+          />
+        </InlineField>
+
+        <InlineField label="Format" tooltip="Output shape of the query, not every mode supports every format" labelWidth={10}>
+          <Select
+            options={[
+              { label: 'Table', value: 'table' },
+              { label: 'Time Series', value: 'timeseries' },
+              { label: 'Numeric', value: 'numeric' },
+              { label: 'State Timeline', value: 'timeline' },
+            ]}
+            value={resourceParams.format || 'table'}
+            onChange={onFormatChange}
+            width={24}
+          />
+        </InlineField>
+      </Stack>
+
       <InlineField label="Job ID" tooltip="Filter runs by job ID" labelWidth={10}>
         <Input
           placeholder="Optional"
@@ -74,6 +134,13 @@ export function JobRunsEditor({
           value={resourceParams.completedOnly || false}
           onChange={onCompletedOnlyChange}
         />
+
+        <InlineSwitch
+          label="Include Cost"
+          showLabel={true}
+          value={resourceParams.includeCost || false}
+          onChange={onIncludeCostChange}
+        />
       </Stack>
 
       <InlineField label="Run Type" tooltip="Filter by run type" labelWidth={14}>
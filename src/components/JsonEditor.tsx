@@ -0,0 +1,58 @@
+import React, { useEffect, useState } from 'react';
+import { InlineField, TextArea } from '@grafana/ui';
+
+interface JsonEditorProps {
+  label: string;
+  tooltip: string;
+  value: object | undefined;
+  placeholder?: string;
+  onChange: (value: any) => void;
+  onRunQuery: () => void;
+}
+
+const format = (value: object | undefined) =>
+  value && Object.keys(value).length > 0 ? JSON.stringify(value, null, 2) : '';
+
+/**
+ * Edits a JSON object as text, for query options that have no dedicated editor yet.
+ * The value is only updated once the text is valid JSON.
+ */
+export function JsonEditor({ label, tooltip, value, placeholder, onChange, onRunQuery }: JsonEditorProps) {
+  // callers pass a new object on every render, so the text is only re-synced when the content changes
+  const formatted = format(value);
+  const [text, setText] = useState(formatted);
+  const [error, setError] = useState<string | undefined>();
+
+  useEffect(() => {
+    setText(formatted);
+  }, [formatted]);
+
+  const onBlur = () => {
+    if (text.trim() === '') {
+      setError(undefined);
+      onChange(undefined);
+      onRunQuery();
+      return;
+    }
+
+    try {
+      onChange(JSON.parse(text));
+      setError(undefined);
+      onRunQuery();
+    } catch (e) {
+      setError(`Invalid JSON: ${(e as Error).message}`);
+    }
+  };
+
+  return (
+    <InlineField label={label} tooltip={tooltip} labelWidth={14} invalid={!!error} error={error} grow>
+      <TextArea
+        value={text}
+        placeholder={placeholder}
+        rows={Math.min(Math.max(text.split('\n').length, 2), 12)}
+        onChange={(event: React.ChangeEvent<HTMLTextAreaElement>) => setText(event.target.value)}
+        onBlur={onBlur}
+      />
+    </InlineField>
+  );
+}
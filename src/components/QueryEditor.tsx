@@ -2,16 +2,45 @@ import React from 'react';
 import { InlineField, Input, Select, Stack } from '@grafana/ui';
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { DataSource } from '../datasource';
-import { JobRunQueryParams, MyDataSourceOptions, MyQuery, PipelineQueryParams } from '../types';
+import {
+  ClusterQueryParams,
+  JobRunQueryParams,
+  MyDataSourceOptions,
+  MyQuery,
+  PipelineQueryParams,
+  ResourceParams,
+} from '../types';
+import ClustersEditor from './ClustersEditor';
 import { JobRunsEditor } from './JobRunsEditor';
+import { JsonEditor } from './JsonEditor';
 import PipelinesEditor from './PipelinesEditor';
 
 type Props = QueryEditorProps<DataSource, MyQuery, MyDataSourceOptions>;
 
-export function QueryEditor({ query, onChange, onRunQuery }: Props) {
+const resourceTypes: Array<SelectableValue<string>> = [
+  { label: 'Job Runs', value: 'job_runs' },
+  { label: 'Job Run Logs', value: 'job_run_logs' },
+  { label: 'Pipelines', value: 'pipelines' },
+  { label: 'Pipeline Updates', value: 'pipeline_updates' },
+  { label: 'Clusters', value: 'clusters' },
+  { label: 'Cluster Events', value: 'cluster_events' },
+  { label: 'SQL Warehouses', value: 'sql_warehouses' },
+  { label: 'Query History', value: 'query_history' },
+  { label: 'Usage', value: 'usage' },
+  { label: 'Tables', value: 'tables' },
+  { label: 'Table History', value: 'table_history' },
+  { label: 'Audit Events', value: 'audit_events' },
+  { label: 'Serving Endpoints', value: 'serving_endpoints' },
+  { label: 'Serving Endpoint Metrics', value: 'serving_endpoint_metrics' },
+  { label: 'MLflow Runs', value: 'mlflow_runs' },
+  { label: 'MLflow Metric History', value: 'mlflow_metric_history' },
+  { label: 'Workspaces', value: 'workspaces', description: 'Account mode only' },
+];
+
+export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props) {
   const resourceParams = query.resourceParams || {};
   const onResourceTypeChange = (value: SelectableValue<string>) => {
-    onChange({ ...query, resourceType: value.value! });
+    onChange({ ...query, resourceType: value.value!, resourceParams: {} });
     onRunQuery();
   };
 
@@ -22,19 +51,45 @@ export function QueryEditor({ query, onChange, onRunQuery }: Props) {
     }
   };
 
+  const onWorkspacesChange = (event: React.ChangeEvent<HTMLInputElement>) => {
+    const workspaces = event.target.value
+      .split(',')
+      .map((w) => w.trim())
+      .filter((w) => w !== '');
+
+    onChange({ ...query, workspaces: workspaces.length > 0 ? workspaces : undefined });
+  };
+
   const handleQueryChange = (updates: Partial<MyQuery>) => {
     onChange({ ...query, ...updates });
   };
 
+  const paramsEditor = (tooltip: string, placeholder: string) => (
+    <JsonEditor
+      label="Params"
+      tooltip={tooltip}
+      value={resourceParams}
+      placeholder={placeholder}
+      onChange={(value) => handleQueryChange({ resourceParams: (value || {}) as ResourceParams })}
+      onRunQuery={onRunQuery}
+    />
+  );
+
   const resourceEditor = () => {
     switch (query.resourceType) {
       case 'job_runs':
         return (
-          <JobRunsEditor
-            resourceParams={resourceParams as JobRunQueryParams}
-            onChange={handleQueryChange}
-            onRunQuery={onRunQuery}
-          />
+          <>
+            <JobRunsEditor
+              resourceParams={resourceParams as JobRunQueryParams}
+              onChange={handleQueryChange}
+              onRunQuery={onRunQuery}
+            />
+            {paramsEditor(
+              'All job runs query parameters as JSON, e.g. SLAs or schedule options (see README)',
+              'e.g. {"mode": "sla", "slas": [{"tag": "team:data", "cadence": "24h", "maxDuration": "2h"}]}'
+            )}
+          </>
         );
 
       case 'pipelines':
@@ -46,36 +101,71 @@ export function QueryEditor({ query, onChange, onRunQuery }: Props) {
           />
         );
 
-      default:
+      case 'clusters':
+        return (
+          <ClustersEditor
+            resourceParams={resourceParams as ClusterQueryParams}
+            onChange={handleQueryChange}
+            onRunQuery={onRunQuery}
+          />
+        );
+
+      case 'workspaces':
         return null;
+
+      default:
+        return paramsEditor(
+          'Query parameters of the resource type as JSON (see README)',
+          'e.g. {"clusterIds": ["0123-456789-abcdef"], "eventTypes": ["RESIZING"]}'
+        );
     }
   };
 
   return (
-    <Stack gap={0}>
-      <InlineField label="Resource Type">
-        <Select
-          options={[
-            { label: 'Job Runs', value: 'job_runs' },
-            { label: 'Pipelines', value: 'pipelines' },
-          ]}
-          value={query.resourceType}
-          onChange={onResourceTypeChange}
-        />
-      </InlineField>
+    <Stack direction="column" gap={0}>
+      <Stack gap={0}>
+        <InlineField label="Resource Type">
+          <Select options={resourceTypes} value={query.resourceType} onChange={onResourceTypeChange} />
+        </InlineField>
+
+        <InlineField label="Max Results" labelWidth={14} tooltip="Maximum number of results to fetch">
+          <Input
+            type="number"
+            placeholder="200"
+            value={query.limit || 200}
+            onChange={onLimitChange}
+            onBlur={onRunQuery}
+            width={12}
+          />
+        </InlineField>
+
+        {datasource.isAccountMode && (
+          <InlineField
+            label="Workspaces"
+            labelWidth={14}
+            tooltip="Comma separated workspace IDs or names to query, all running workspaces when empty"
+          >
+            <Input
+              placeholder="All"
+              defaultValue={(query.workspaces || []).join(', ')}
+              onChange={onWorkspacesChange}
+              onBlur={onRunQuery}
+              width={32}
+            />
+          </InlineField>
+        )}
+      </Stack>
 
       {resourceEditor()}
 
-      <InlineField label="Max Results" labelWidth={14} tooltip="Maximum number of results to fetch">
-        <Input
-          type="number"
-          placeholder="200"
-          value={query.limit || 200}
-          onChange={onLimitChange}
-          onBlur={onRunQuery}
-          width={12}
-        />
-      </InlineField>
+      <JsonEditor
+        label="Transform"
+        tooltip="Filters, grouping, sorting and top N applied to the results (see README)"
+        value={query.transform}
+        placeholder='e.g. {"filters": [{"field": "Result State", "op": "in", "values": ["FAILED"]}], "topN": 10}'
+        onChange={(transform) => handleQueryChange({ transform })}
+        onRunQuery={onRunQuery}
+      />
     </Stack>
   );
 }
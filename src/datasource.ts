@@ -4,8 +4,12 @@ import { DataSourceWithBackend } from '@grafana/runtime';
 import { MyQuery, MyDataSourceOptions, DEFAULT_QUERY } from './types';
 
 export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptions> {
+  // queries fan out to the workspaces of the account, and can select them
+  isAccountMode: boolean;
+
   constructor(instanceSettings: DataSourceInstanceSettings<MyDataSourceOptions>) {
     super(instanceSettings);
+    this.isAccountMode = !!instanceSettings.jsonData.accountId;
   }
 
   getDefaultQuery(_: CoreApp): Partial<MyQuery> {
@@ -5,6 +5,8 @@ export interface MyQuery extends DataQuery {
   resourceType: string;
   resourceParams: ResourceParams;
   limit?: number;
+  transform?: QueryTransform;
+  workspaces?: string[];
 }
 
 export const DEFAULT_QUERY: Partial<MyQuery> = {
@@ -12,24 +14,49 @@ export const DEFAULT_QUERY: Partial<MyQuery> = {
   limit: 200
 };
 
-export type ResourceParams = JobRunQueryParams | PipelineQueryParams;
+export type ResourceParams = JobRunQueryParams | PipelineQueryParams | ClusterQueryParams;
 
 export interface JobRunQueryParams {
+  mode?: 'runs' | 'schedule' | 'sla' | 'flakiness' | 'reliability';
+  format?: 'table' | 'timeseries' | 'numeric' | 'timeline';
   jobId?: string;
   activeOnly?: boolean;
   completedOnly?: boolean;
   runType?: 'JOB_RUN' | 'WORKFLOW_RUN' | 'SUBMIT_RUN';
+  includeCost?: boolean;
 }
 
 export interface PipelineQueryParams {
   filter?: string;
 }
 
+export interface ClusterQueryParams {
+  states?: string[];
+  clusterSources?: string[];
+}
+
+export interface QueryTransform {
+  filters?: Array<{
+    field: string;
+    op: 'eq' | 'neq' | 'in' | 'not_in' | 'regex' | 'not_regex' | 'gt' | 'gte' | 'lt' | 'lte';
+    value?: string;
+    values?: string[];
+  }>;
+  groupBy?: string;
+  aggregations?: Array<{ func: 'count' | 'sum' | 'avg' | 'min' | 'max'; field?: string }>;
+  sortBy?: string;
+  sortDesc?: boolean;
+  topN?: number;
+}
+
 /**
  * These are options configured for each DataSource instance
  */
 export interface MyDataSourceOptions extends DataSourceJsonData {
   workspace?: string;
+  warehouseId?: string;
+  accountId?: string;
+  accountHost?: string;
 }
 
 /**